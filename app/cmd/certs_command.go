@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/leonardinius/go-service-template/internal/devcerts"
+)
+
+const (
+	certsDefaultDir = "certs"
+	certsCAName     = "ca"
+	certsServerName = "server"
+)
+
+type certsCommand struct {
+	c        *cobra.Command
+	logLevel string
+	dir      string
+	sans     []string
+	clients  []string
+	validFor time.Duration
+}
+
+func CreateCertsCommand(context.Context) *certsCommand {
+	r := certsCommand{}
+
+	r.c = &cobra.Command{
+		Use:   "certs",
+		Short: "Generate development TLS certificates",
+		Long: "`certs` generates the local CA, the server certificate for the SANs and the client certificates into the directory:\n" +
+			"\tca.pem, ca-key.pem, server.pem, server-key.pem, client-<name>.pem, client-<name>-key.pem\n" +
+			"The existing CA is reused, so the clients keep trusting the regenerated certificates.\n" +
+			"Example:\n" +
+			"\tcerts --dir ./certs --san localhost --san 127.0.0.1 --client alice\n" +
+			"\thttp --tls-cert ./certs/server.pem --tls-key ./certs/server-key.pem --tls-client-ca ./certs/ca.pem",
+		//nolint:contextcheck // cobra interface
+		PreRun: func(cmd *cobra.Command, args []string) {
+			cmd.SilenceUsage = true
+			MustSetupLogger(cmd.Context(), r.logLevel)
+		},
+
+		//nolint:contextcheck // cobra interface
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.execute(cmd.Context())
+		},
+		Args: cobra.NoArgs,
+	}
+
+	r.c.Flags().StringVar(&r.dir, "dir", certsDefaultDir, "Output directory (DIR)")
+	r.c.Flags().StringSliceVar(&r.sans, "san", devcerts.DefaultSANs(), "Server certificate DNS names and IP addresses")
+	r.c.Flags().StringSliceVar(&r.clients, "client", []string{"dev"}, "Client certificate common names")
+	r.c.Flags().DurationVar(&r.validFor, "valid-for", devcerts.DefaultValidFor, "Certificate validity")
+	r.c.PersistentFlags().StringVar(&r.logLevel, "log-level", "info", "log level: debug, info, warn, error")
+	return &r
+}
+
+func (r *certsCommand) Command() *cobra.Command {
+	return r.c
+}
+
+func (r *certsCommand) execute(ctx context.Context) error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil { //nolint:gosec // the certificates are public
+		return err
+	}
+
+	ca, err := r.loadOrCreateCA(ctx)
+	if err != nil {
+		return err
+	}
+
+	server, err := ca.IssueServer(r.sans, r.validFor)
+	if err != nil {
+		return err
+	}
+	if err = r.write(ctx, server, certsServerName); err != nil {
+		return err
+	}
+
+	for _, name := range r.clients {
+		client, err := ca.IssueClient(name, r.validFor)
+		if err != nil {
+			return err
+		}
+		if err = r.write(ctx, client, "client-"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadOrCreateCA loads the CA from the directory, or generates and writes the new one if neither CA file exists.
+func (r *certsCommand) loadOrCreateCA(ctx context.Context) (*devcerts.Cert, error) {
+	certFile := filepath.Join(r.dir, certsCAName+".pem")
+	keyFile := filepath.Join(r.dir, certsCAName+"-key.pem")
+	ca, err := devcerts.LoadCA(certFile, keyFile)
+	if err == nil {
+		slog.LogAttrs(ctx, slog.LevelInfo, "reusing the CA certificate", slog.String("cert", certFile))
+		return ca, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if ca, err = devcerts.NewCA(devcerts.Organization+" CA", r.validFor); err != nil {
+		return nil, err
+	}
+	return ca, r.write(ctx, ca, certsCAName)
+}
+
+func (r *certsCommand) write(ctx context.Context, cert *devcerts.Cert, name string) error {
+	certFile, keyFile, err := cert.WriteFiles(r.dir, name)
+	if err != nil {
+		return err
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "certificate generated",
+		slog.String("subject", cert.Cert.Subject.CommonName),
+		slog.String("cert", certFile),
+		slog.String("key", keyFile),
+		slog.Time("not_after", cert.Cert.NotAfter))
+	return nil
+}
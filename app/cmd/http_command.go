@@ -2,13 +2,19 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"log/slog"
 	"net"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/devcerts"
+	"github.com/leonardinius/go-service-template/internal/health"
 	"github.com/leonardinius/go-service-template/internal/services"
 	"github.com/leonardinius/go-service-template/internal/services/version"
 )
@@ -18,10 +24,30 @@ const (
 	httpDefaultListenAddress = "localhost:8080"
 )
 
+var (
+	errTLSCertAndKey = errors.New("--tls-cert and --tls-key are required to serve TLS")
+	errTLSDevAndCert = errors.New("--tls-dev generates the certificate, --tls-cert and --tls-key are not allowed")
+)
+
 type httpCommand struct {
 	c             *cobra.Command
 	logLevel      string
 	listenAddress string
+	//--shutdown--
+	preStopDelay    time.Duration
+	shutdownTimeout time.Duration
+	//--tls--
+	tlsCert       string
+	tlsKey        string
+	tlsClientCA   string
+	tlsClientAuth string
+	tlsDev        bool
+	//--cors--
+	corsOrigins        []string
+	corsMethods        []string
+	corsHeaders        []string
+	corsExposedHeaders []string
+	corsMaxAge         time.Duration
 }
 
 func CreateHTTPServeCommand(context.Context) *httpCommand {
@@ -32,7 +58,11 @@ func CreateHTTPServeCommand(context.Context) *httpCommand {
 		Short: "Run {HTTP, gRPC} server",
 		Long: "`http` starts an HTTP server on the specified address and port. Default is " + httpDefaultListenAddress + "." +
 			"\n" +
-			"The server is HTTP & gRPC-compatible (see https://connectrpc.com for more details).",
+			"The server is HTTP & gRPC-compatible (see https://connectrpc.com for more details).\n" +
+			"Metrics are exposed on /metrics, the liveness and readiness probes on /healthz and /readyz.\n" +
+			"HTTPS and mutual TLS are served with the --tls-cert, --tls-key and --tls-client-ca flags,\n" +
+			"or with the development certificate generated in memory with --tls-dev.\n" +
+			"Browser clients, e.g. Connect and gRPC-web, are allowed with the --cors-origin flag.",
 		//nolint:contextcheck // cobra interface
 		PreRun: func(cmd *cobra.Command, args []string) {
 			// Do not print usage on error, eg when port is already in use.
@@ -48,6 +78,26 @@ func CreateHTTPServeCommand(context.Context) *httpCommand {
 	}
 
 	r.c.Flags().StringVarP(&r.listenAddress, "address", "a", httpDefaultListenAddress, "[[host]:port] listen address")
+	r.c.Flags().DurationVar(&r.preStopDelay, "pre-stop-delay", apiserv.DefaultPreStopDelay,
+		"Lame duck delay on shutdown: /readyz fails, but requests are still served, e.g. until the load balancer notices")
+	r.c.Flags().DurationVar(&r.shutdownTimeout, "shutdown-timeout", apiserv.DefaultShutdownTimeout,
+		"Shutdown deadline to complete the active requests, the remaining connections are closed")
+	r.c.Flags().StringVar(&r.tlsCert, "tls-cert", "", "TLS server certificate, reloaded on change (FILE)")
+	r.c.Flags().StringVar(&r.tlsKey, "tls-key", "", "TLS server private key, reloaded on change (FILE)")
+	r.c.Flags().BoolVar(&r.tlsDev, "tls-dev", false,
+		"Serve TLS with the development certificate for the listen host and localhost generated in memory, see `certs`")
+	r.c.Flags().StringVar(&r.tlsClientCA, "tls-client-ca", "", "TLS certificate authorities to verify the client certificates (FILE)")
+	r.c.Flags().StringVar(&r.tlsClientAuth, "tls-client-auth", "",
+		"TLS client certificate policy: "+strings.Join(apiserv.ClientAuthPolicies(), ", ")+
+			"; require-and-verify if --tls-client-ca is set, none otherwise")
+	r.c.Flags().StringSliceVar(&r.corsOrigins, "cors-origin", nil,
+		"Origins allowed to make cross-origin requests, e.g. https://*.example.com or *; none disables CORS")
+	r.c.Flags().StringSliceVar(&r.corsMethods, "cors-method", apiserv.CORSDefaultAllowedMethods(), "Methods allowed in cross-origin requests")
+	r.c.Flags().StringSliceVar(&r.corsHeaders, "cors-header", apiserv.CORSDefaultAllowedHeaders(),
+		"Request headers allowed in cross-origin requests")
+	r.c.Flags().StringSliceVar(&r.corsExposedHeaders, "cors-exposed-header", apiserv.CORSDefaultExposedHeaders(),
+		"Response headers exposed to cross-origin requests")
+	r.c.Flags().DurationVar(&r.corsMaxAge, "cors-max-age", apiserv.DefaultCORSMaxAge, "Preflight response cache duration")
 	r.c.PersistentFlags().StringVar(&r.logLevel, "log-level", "info", "log level: debug, info, warn, error")
 	return &r
 }
@@ -68,25 +118,97 @@ func (r *httpCommand) runServe(ctx context.Context, address string) error {
 		address = net.JoinHostPort(address, httpDefaultListenPort)
 	}
 
+	listenOptions, err := r.listenOptions(ctx, address)
+	if err != nil {
+		return err
+	}
+
 	slog.LogAttrs(ctx, slog.LevelInfo, "starting http",
 		slog.String("version", version.FullVersion),
-		slog.String("address", address))
-
-	srv, err := apiserv.NewDefaultServer(ctx, address, services.AllRoutes...)
+		slog.String("address", address),
+		slog.Bool("tls", len(listenOptions) > 0))
+
+	srv, err := apiserv.NewDefaultServer(ctx, address, services.AllRoutes,
+		apiserv.WithCORSAllowedOrigins(r.corsOrigins...),
+		apiserv.WithCORSAllowedMethods(r.corsMethods...),
+		apiserv.WithCORSAllowedHeaders(r.corsHeaders...),
+		apiserv.WithCORSExposedHeaders(r.corsExposedHeaders...),
+		apiserv.WithCORSMaxAge(r.corsMaxAge),
+	)
 	if err != nil {
 		return err
 	}
 
+	routePaths := make([]string, 0, len(services.AllRoutes))
+	for _, route := range services.AllRoutes {
+		routePaths = append(routePaths, route.Path())
+	}
+	registry := health.RegistryFromContext(ctx)
+	registry.SetServing(health.StatusServing, routePaths...)
+
+	conns := apiserv.TrackConnections(ctx, srv)
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- apiserv.ListenAndServe(ctx, srv)
+		// the requests are not canceled on signal, they complete during the shutdown
+		errCh <- apiserv.ListenAndServe(context.WithoutCancel(ctx), srv, listenOptions...)
 	}()
 
 	select {
 	case err := <-errCh:
+		registry.SetServing(health.StatusNotServing, routePaths...)
 		return err
 	case <-ctx.Done():
 		slog.LogAttrs(ctx, slog.LevelInfo, "signal received, shutting down http server...", slog.String("address", address))
-		return srv.Shutdown(context.WithoutCancel(ctx))
+		registry.SetServing(health.StatusNotServing, routePaths...)
+		return apiserv.Shutdown(context.WithoutCancel(ctx), srv, conns,
+			apiserv.WithPreStopDelay(r.preStopDelay),
+			apiserv.WithShutdownTimeout(r.shutdownTimeout))
+	}
+}
+
+// listenOptions returns the TLS listen options if the TLS flags are set.
+func (r *httpCommand) listenOptions(ctx context.Context, address string) ([]apiserv.ListenOption, error) {
+	if !r.tlsDev && r.tlsCert == "" && r.tlsKey == "" && r.tlsClientCA == "" && r.tlsClientAuth == "" {
+		return nil, nil
+	}
+	if r.tlsDev && (r.tlsCert != "" || r.tlsKey != "") {
+		return nil, errTLSDevAndCert
+	}
+	if !r.tlsDev && (r.tlsCert == "" || r.tlsKey == "") {
+		return nil, errTLSCertAndKey
+	}
+
+	clientAuth := tls.NoClientCert
+	if r.tlsClientCA != "" {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	if r.tlsClientAuth != "" {
+		var err error
+		if clientAuth, err = apiserv.ParseClientAuth(r.tlsClientAuth); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		tlsConfig *tls.Config
+		err       error
+	)
+	if r.tlsDev {
+		tlsConfig, err = apiserv.NewDevServerTLSConfig(devSANs(address), r.tlsClientCA, clientAuth)
+	} else {
+		tlsConfig, err = apiserv.NewServerTLSConfig(ctx, r.tlsCert, r.tlsKey, r.tlsClientCA, clientAuth)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []apiserv.ListenOption{apiserv.WithListenTLS(tlsConfig)}, nil
+}
+
+// devSANs returns the development certificate SANs: the listen host and localhost, see devcerts.DefaultSANs.
+func devSANs(address string) []string {
+	sans := devcerts.DefaultSANs()
+	if host, _, err := net.SplitHostPort(address); err == nil && host != "" && !slices.Contains(sans, host) {
+		sans = append(sans, host)
 	}
+	return sans
 }
@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"net"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/spf13/cobra"
@@ -34,6 +36,43 @@ type natsCommand struct {
 	tlscert  string
 	tlskey   string
 	tlsca    string
+	context  string
+	queue    string
+	admin    bool
+	micro    bool
+	//--embedded--
+	embedded          bool
+	embeddedAddress   string
+	embeddedJetStream bool
+	embeddedStoreDir  string
+	//--subjects--
+	subjectPrefix string
+	routeSubjects map[string]string
+	//--connection--
+	maxReconnects        int
+	reconnectWait        time.Duration
+	reconnectJitter      time.Duration
+	reconnectJitterTLS   time.Duration
+	pingInterval         time.Duration
+	reconnectBufSize     int
+	connectTimeout       time.Duration
+	retryOnFailedConnect bool
+	//--deadlines--
+	defaultTimeout time.Duration
+	maxTimeout     time.Duration
+	drainTimeout   time.Duration
+	stuckTimeout   time.Duration
+	//--concurrency--
+	maxInFlight  int
+	pendingMsgs  int
+	pendingBytes int
+	//--jetstream--
+	jetstream  bool
+	stream     string
+	durable    string
+	maxDeliver int
+	nakDelay   time.Duration
+	ackWait    time.Duration
 }
 
 func CreateAPIWorkerCommand(context.Context) *natsCommand {
@@ -42,9 +81,12 @@ func CreateAPIWorkerCommand(context.Context) *natsCommand {
 	r.c = &cobra.Command{
 		Use:   "nats",
 		Short: "Run NATS.io worker",
-		Long: "`nats` starts an NATS.io worker. Additionally exposes metrics on http://[metrics]/metrics.\n" +
+		Long: "`nats` starts an NATS.io worker. Additionally exposes metrics on http://[metrics]/metrics\n" +
+			"and liveness/readiness probes on http://[metrics]/healthz and http://[metrics]/readyz.\n" +
 			"Example:\n" +
-			"\tnats --server nats://localhost:4222 --user user --password password",
+			"\tnats --server nats://localhost:4222 --user user --password password\n" +
+			"\tnats --context dev --queue workers --micro\n" +
+			"\tnats --embedded --jetstream --embedded-store-dir ./data",
 		//nolint:contextcheck // cobra interface
 		PreRun: func(cmd *cobra.Command, args []string) {
 			// Do not print usage on error, eg when port is already in use.
@@ -69,6 +111,53 @@ func CreateAPIWorkerCommand(context.Context) *natsCommand {
 	r.c.Flags().StringVar(&r.tlscert, "tlscert", "", "TLS public certificate (FILE)")
 	r.c.Flags().StringVar(&r.tlskey, "tlskey", "", "TLS private key (FILE)")
 	r.c.Flags().StringVar(&r.tlsca, "tlsca", "", "TLS certificate authority chain (FILE)")
+	r.c.Flags().StringVar(&r.context, "context", "", "NATS CLI context name or context file, explicit flags take precedence (NAME)")
+	r.c.Flags().StringVar(&r.queue, "queue", version.ServiceName, "Queue group name, workers in the same group load-balance requests (QUEUE)")
+	r.c.Flags().BoolVar(&r.admin, "admin", true,
+		"Answer [PREFIX.]_ADMIN.<service>.> broadcast subjects: STATUS, VERSION, INFLIGHT, LOGLEVEL and DRAIN")
+	r.c.Flags().BoolVar(&r.micro, "micro", false,
+		"Register the routes as NATS.io micro service endpoints, discoverable with $SRV.PING, $SRV.INFO and $SRV.STATS, "+
+			"the endpoints handle one request at a time, ignores --max-inflight, not supported with --pending-msgs and --pending-bytes")
+	r.c.Flags().BoolVar(&r.embedded, "embedded", false,
+		"Run the embedded NATS server in the worker process, ignores --server and --context")
+	r.c.Flags().StringVar(&r.embeddedAddress, "embedded-listen", apiworker.DefaultEmbeddedAddress, "Embedded NATS server listen address")
+	r.c.Flags().BoolVar(&r.embeddedJetStream, "embedded-jetstream", false,
+		"Enable JetStream on the embedded NATS server, implied by --jetstream")
+	r.c.Flags().StringVar(&r.embeddedStoreDir, "embedded-store-dir", "", "Embedded NATS server JetStream data directory (DIR)")
+	r.c.Flags().StringVar(&r.subjectPrefix, "subject-prefix", "", "Subject namespace of all routes, e.g. prod.svcname. (PREFIX)")
+	r.c.Flags().StringToStringVar(&r.routeSubjects, "route-subject", nil,
+		"Route subject overrides, e.g. /version.v1.VersionService/=version (PATH=SUBJECT)")
+	r.c.Flags().IntVar(&r.maxReconnects, "max-reconnects", nats.DefaultMaxReconnect, "Reconnect attempts before giving up, -1 retries forever")
+	r.c.Flags().DurationVar(&r.reconnectWait, "reconnect-wait", nats.DefaultReconnectWait, "Wait time between reconnect attempts")
+	r.c.Flags().DurationVar(&r.reconnectJitter, "reconnect-jitter", nats.DefaultReconnectJitter, "Random delay added to the reconnect wait")
+	r.c.Flags().DurationVar(&r.reconnectJitterTLS, "reconnect-jitter-tls", nats.DefaultReconnectJitterTLS,
+		"Random delay added to the reconnect wait for TLS connections")
+	r.c.Flags().DurationVar(&r.pingInterval, "ping-interval", nats.DefaultPingInterval, "Interval of pings to detect stale connections")
+	r.c.Flags().IntVar(&r.reconnectBufSize, "reconnect-buffer-size", nats.DefaultReconnectBufSize,
+		"Bytes of published messages buffered while reconnecting, -1 disables the buffer")
+	r.c.Flags().DurationVar(&r.connectTimeout, "connect-timeout", nats.DefaultTimeout, "Server dial timeout")
+	r.c.Flags().BoolVar(&r.retryOnFailedConnect, "retry-on-failed-connect", false,
+		"Keep reconnecting if no server is available at startup instead of exiting")
+	r.c.Flags().DurationVar(&r.defaultTimeout, "default-timeout", 30*time.Second,
+		"Request timeout if the request has no Connect-Timeout-Ms or Grpc-Timeout header, 0 disables")
+	r.c.Flags().DurationVar(&r.maxTimeout, "max-timeout", 5*time.Minute, "Maximum request timeout, 0 disables")
+	r.c.Flags().DurationVar(&r.drainTimeout, "drain-timeout", nats.DefaultDrainTimeout,
+		"Shutdown deadline to complete the requests in flight, the remaining requests are abandoned")
+	r.c.Flags().DurationVar(&r.stuckTimeout, "dispatch-stuck-timeout", apiworker.DefaultDispatchStuckTimeout,
+		"Liveness probe fails once a route has pending messages, but dispatched no requests for the timeout")
+	r.c.Flags().IntVar(&r.maxInFlight, "max-inflight", runtime.NumCPU(), "Maximum number of requests handled concurrently per route")
+	r.c.Flags().IntVar(&r.pendingMsgs, "pending-msgs", 0,
+		"Pending messages limit per subscription before messages are dropped, 0 is the client default, -1 is unlimited")
+	r.c.Flags().IntVar(&r.pendingBytes, "pending-bytes", 0,
+		"Pending bytes limit per subscription before messages are dropped, 0 is the client default, -1 is unlimited")
+	r.c.Flags().BoolVar(&r.jetstream, "jetstream", false, "Consume requests from JetStream durable consumers instead of core NATS.io")
+	r.c.Flags().StringVar(&r.stream, "stream", version.ServiceName, "JetStream stream name (STREAM)")
+	r.c.Flags().StringVar(&r.durable, "durable", version.ServiceName, "JetStream durable consumer name prefix (DURABLE)")
+	r.c.Flags().IntVar(&r.maxDeliver, "max-deliver", 5, "JetStream max delivery attempts for failed (5xx) requests")
+	r.c.Flags().DurationVar(&r.ackWait, "ack-wait", 30*time.Second,
+		"JetStream ack wait before the redelivery, extended while the request is handled")
+	r.c.Flags().DurationVar(&r.nakDelay, "nak-delay", time.Second,
+		"JetStream base redelivery delay for failed (5xx) requests, doubles on each attempt")
 	return &r
 }
 
@@ -98,15 +187,32 @@ func (r *natsCommand) runWorker(ctx context.Context, metricsAddress string) erro
 		slog.String("nkey", r.nkey),
 		slog.String("tlscert", r.tlscert),
 		slog.String("tlskey", r.tlskey),
-		slog.String("tlsca", r.tlsca))
+		slog.String("tlsca", r.tlsca),
+		slog.String("context", r.context),
+		slog.String("queue", r.queue),
+		slog.Bool("admin", r.admin),
+		slog.Bool("micro", r.micro),
+		slog.Bool("embedded", r.embedded),
+		slog.String("subject_prefix", r.subjectPrefix),
+		slog.Any("route_subjects", r.routeSubjects),
+		slog.Int("max_reconnects", r.maxReconnects),
+		slog.Duration("reconnect_wait", r.reconnectWait),
+		slog.Duration("ping_interval", r.pingInterval),
+		slog.Bool("retry_on_failed_connect", r.retryOnFailedConnect),
+		slog.Duration("default_timeout", r.defaultTimeout),
+		slog.Duration("max_timeout", r.maxTimeout),
+		slog.Duration("drain_timeout", r.drainTimeout),
+		slog.Duration("dispatch_stuck_timeout", r.stuckTimeout),
+		slog.Int("max_inflight", r.maxInFlight),
+		slog.Int("pending_msgs", r.pendingMsgs),
+		slog.Int("pending_bytes", r.pendingBytes),
+		slog.Bool("jetstream", r.jetstream),
+		slog.String("stream", r.stream),
+		slog.String("durable", r.durable))
 
-	url := r.url
-	if r.url == "" {
-		url = nats.DefaultURL
-	}
 	options := r.natsOptions()
 
-	wrk, err := apiworker.NewWorker(ctx, url, services.AllRoutes, options...)
+	wrk, err := apiworker.NewWorker(ctx, nats.DefaultURL, services.AllRoutes, options...)
 	if err != nil {
 		return err
 	}
@@ -120,19 +226,26 @@ func (r *natsCommand) runWorker(ctx context.Context, metricsAddress string) erro
 	case err := <-errCh:
 		return err
 	case <-ctx.Done():
-		slog.LogAttrs(ctx, slog.LevelInfo, "shutting down nats worker...", slog.String("server", url))
+		slog.LogAttrs(ctx, slog.LevelInfo, "shutting down nats worker...", slog.String("server", r.url))
 		return wrk.Shutdown(context.WithoutCancel(ctx))
 	}
 }
 
 func (r *natsCommand) natsOptions() []apiworker.Option {
 	var options []apiworker.Option
+	if r.micro {
+		options = append(options, apiworker.WithMicroService(version.ServiceName, version.FullVersion))
+	}
 	if r.metricsAddress != "" {
 		options = append(options, apiworker.WithMetricsAddress(r.metricsAddress))
 	}
-	if r.url != "" {
+	// the default server URL must not override the context
+	if !r.embedded && r.url != "" && (r.context == "" || r.c.Flags().Changed("server")) {
 		options = append(options, apiworker.WithURL(r.url))
 	}
+	if !r.embedded && r.context != "" {
+		options = append(options, apiworker.WithContextName(r.context))
+	}
 	if r.user != "" {
 		options = append(options, apiworker.WithUser(r.user))
 	}
@@ -159,6 +272,47 @@ func (r *natsCommand) natsOptions() []apiworker.Option {
 		tlsca := mustExpandPath(r.tlsca)
 		options = append(options, apiworker.WithTLSCA(tlsca))
 	}
+	if r.queue != "" {
+		options = append(options, apiworker.WithQueueGroup(r.queue))
+	}
+	if r.admin {
+		options = append(options, apiworker.WithAdmin(version.ServiceName))
+	}
+	if r.embedded {
+		options = append(options, apiworker.WithEmbeddedServer(r.embeddedAddress))
+		if r.embeddedJetStream || r.jetstream || r.embeddedStoreDir != "" {
+			options = append(options, apiworker.WithEmbeddedJetStream(mustExpandPath(r.embeddedStoreDir)))
+		}
+	}
+	if r.subjectPrefix != "" {
+		options = append(options, apiworker.WithSubjectPrefix(r.subjectPrefix))
+	}
+	for path, subject := range r.routeSubjects {
+		options = append(options, apiworker.WithRouteSubject(path, subject))
+	}
+	options = append(options,
+		apiworker.WithMaxReconnects(r.maxReconnects),
+		apiworker.WithReconnectWait(r.reconnectWait),
+		apiworker.WithReconnectJitter(r.reconnectJitter, r.reconnectJitterTLS),
+		apiworker.WithPingInterval(r.pingInterval),
+		apiworker.WithReconnectBufferSize(r.reconnectBufSize),
+		apiworker.WithConnectTimeout(r.connectTimeout),
+		apiworker.WithRetryOnFailedConnect(r.retryOnFailedConnect),
+		apiworker.WithDefaultTimeout(r.defaultTimeout),
+		apiworker.WithMaxTimeout(r.maxTimeout),
+		apiworker.WithDrainTimeout(r.drainTimeout),
+		apiworker.WithDispatchStuckTimeout(r.stuckTimeout),
+		apiworker.WithMaxInFlight(r.maxInFlight),
+		apiworker.WithPendingLimits(r.pendingMsgs, r.pendingBytes),
+	)
+	if r.jetstream {
+		options = append(options,
+			apiworker.WithJetStream(r.stream, r.durable),
+			apiworker.WithMaxDeliver(r.maxDeliver),
+			apiworker.WithNakDelay(r.nakDelay),
+			apiworker.WithAckWait(r.ackWait),
+		)
+	}
 	return options
 }
 
@@ -49,6 +49,7 @@ func Execute(ctx context.Context, args []string) error {
 	rootCmd := CreateRootCommand(ctx).Command()
 	rootCmd.AddCommand(CreateHTTPServeCommand(ctx).Command())
 	rootCmd.AddCommand(CreateAPIWorkerCommand(ctx).Command())
+	rootCmd.AddCommand(CreateCertsCommand(ctx).Command())
 
 	rootCmd.SetArgs(args)
 
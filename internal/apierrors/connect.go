@@ -0,0 +1,43 @@
+package apierrors
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+)
+
+type interceptor struct{}
+
+var _ connect.Interceptor = (*interceptor)(nil)
+
+// NewInterceptor returns the interceptor rendering the handler errors as Connect errors with
+// the shared.v1.Error detail, see Error.ConnectError.
+func NewInterceptor() connect.Interceptor {
+	return &interceptor{}
+}
+
+// WrapUnary implements connect.Interceptor.
+func (*interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err != nil && !req.Spec().IsClient {
+			return nil, From(err).ConnectError(ctx)
+		}
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (*interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (*interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := next(ctx, conn); err != nil {
+			return From(err).ConnectError(ctx)
+		}
+		return nil
+	}
+}
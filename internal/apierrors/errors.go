@@ -0,0 +1,208 @@
+// Package apierrors is the error model shared by the HTTP, Connect (gRPC) and NATS.io transports.
+//
+// Domain errors carry a connect.Code, details and nested field errors. They render as the shared.v1.Error message:
+// as the Connect error detail, see ConnectError, and as the JSON body of the HTTP and NATS.io gateway errors, see Proto.
+// The stack is rendered at debug log level only.
+package apierrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"connectrpc.com/connect"
+
+	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
+)
+
+// internalMessage is the message of the internal errors, see Internal.
+const internalMessage = "internal error"
+
+const maxStackDepth = 32
+
+// Error is the domain error.
+type Error struct {
+	code    connect.Code
+	message string
+	details []string
+	fields  map[string]string
+	cause   error
+	stack   []byte
+}
+
+// Option configures the Error.
+type Option func(*Error)
+
+// WithDetails adds the error details.
+func WithDetails(details ...string) Option {
+	return func(e *Error) {
+		e.details = append(e.details, details...)
+	}
+}
+
+// WithField adds the nested field error, e.g. the field validation violation.
+func WithField(field, reason string) Option {
+	return func(e *Error) {
+		if e.fields == nil {
+			e.fields = make(map[string]string)
+		}
+		e.fields[field] = reason
+	}
+}
+
+// WithFields adds the nested field errors, see WithField.
+func WithFields(fields map[string]string) Option {
+	return func(e *Error) {
+		for field, reason := range fields {
+			WithField(field, reason)(e)
+		}
+	}
+}
+
+// WithStack replaces the stack captured on the error creation, e.g. with the stack of the recovered panic.
+func WithStack(stack []byte) Option {
+	return func(e *Error) {
+		e.stack = stack
+	}
+}
+
+// New returns the domain error.
+func New(code connect.Code, message string, options ...Option) *Error {
+	e := &Error{code: code, message: message, stack: callersStack()}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
+
+// Wrap returns the domain error caused by err, the message is the err message.
+func Wrap(code connect.Code, err error, options ...Option) *Error {
+	e := New(code, err.Error(), options...)
+	e.cause = err
+	return e
+}
+
+// Internal returns the internal error caused by err. The err message is not exposed, it is rendered at debug log level only.
+func Internal(err error, options ...Option) *Error {
+	e := New(connect.CodeInternal, internalMessage, options...)
+	e.cause = err
+	return e
+}
+
+// From returns err as the domain error. Connect errors and context errors keep their code and message,
+// other errors are internal errors. Returns nil if err is nil.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if e := new(Error); errors.As(err, &e) {
+		return e
+	}
+	if connectErr := new(connect.Error); errors.As(err, &connectErr) {
+		e := &Error{code: connectErr.Code(), message: connectErr.Message(), cause: err}
+		if shared := sharedErrorDetail(connectErr); shared != nil {
+			e.details = shared.GetDetails()
+			e.fields = shared.GetNestedErrors()
+			e.stack = shared.GetStack()
+		}
+		return e
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{code: connect.CodeDeadlineExceeded, message: err.Error(), cause: err}
+	case errors.Is(err, context.Canceled):
+		return &Error{code: connect.CodeCanceled, message: err.Error(), cause: err}
+	}
+	return Internal(err)
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.code.String() + ": " + e.message
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Code returns the error code.
+func (e *Error) Code() connect.Code {
+	return e.code
+}
+
+// Message returns the error message, without the code.
+func (e *Error) Message() string {
+	return e.message
+}
+
+// HTTPStatus returns the HTTP status code of the error code, see HTTPStatus.
+func (e *Error) HTTPStatus() int {
+	return HTTPStatus(e.code)
+}
+
+// Proto returns the error as the shared.v1.Error message, the code is the HTTP status code.
+// The stack, and the cause if its message is not the error message, are set at debug log level only.
+func (e *Error) Proto(ctx context.Context) *sharedv1.Error {
+	shared := &sharedv1.Error{
+		Code:         int32(e.HTTPStatus()), //nolint:gosec // HTTP status code fits int32
+		Message:      e.message,
+		NestedErrors: e.fields,
+		Details:      e.details,
+	}
+	if slog.Default().Enabled(ctx, slog.LevelDebug) {
+		if e.cause != nil && e.cause.Error() != e.message {
+			shared.Details = append(shared.Details, e.cause.Error())
+		}
+		shared.Stack = e.stack
+	}
+	return shared
+}
+
+// ConnectError returns the error as the Connect error with the shared.v1.Error detail, see Proto.
+func (e *Error) ConnectError(ctx context.Context) *connect.Error {
+	connectErr := new(connect.Error)
+	if !errors.As(e.cause, &connectErr) || connectErr.Code() != e.code {
+		connectErr = connect.NewError(e.code, errors.New(e.message)) //nolint:err113 // the message of the domain error
+	}
+	if sharedErrorDetail(connectErr) == nil {
+		if detail, err := connect.NewErrorDetail(e.Proto(ctx)); err == nil {
+			connectErr.AddDetail(detail)
+		}
+	}
+	return connectErr
+}
+
+// sharedErrorDetail returns the shared.v1.Error detail of the Connect error, or nil.
+func sharedErrorDetail(connectErr *connect.Error) *sharedv1.Error {
+	for _, detail := range connectErr.Details() {
+		if value, err := detail.Value(); err == nil {
+			if shared, ok := value.(*sharedv1.Error); ok {
+				return shared
+			}
+		}
+	}
+	return nil
+}
+
+// callersStack returns the stack of the error creation caller.
+func callersStack() []byte {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers and callersStack, the apierrors frames are skipped below
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack strings.Builder
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/leonardinius/go-service-template/internal/apierrors.") {
+			_, _ = fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return []byte(stack.String())
+}
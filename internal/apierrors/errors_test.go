@@ -0,0 +1,104 @@
+package apierrors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/leonardinius/go-service-template/internal/apierrors"
+	"github.com/leonardinius/go-service-template/internal/log"
+
+	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
+)
+
+func TestErrorRendersConsistently(t *testing.T) {
+	t.Parallel()
+	// arrange: a domain error with details and nested field errors
+	domainErr := apierrors.New(connect.CodeNotFound, "version not found",
+		apierrors.WithDetails("no such ref"),
+		apierrors.WithField("RefName", "unknown ref"))
+	err := fmt.Errorf("get version: %w", domainErr)
+
+	// act: render as the Connect error
+	connectErr := apierrors.From(err).ConnectError(t.Context())
+
+	// assert: the Connect error has the code, the message and the shared.v1.Error detail
+	assert.Equal(t, connect.CodeNotFound, connectErr.Code())
+	assert.Equal(t, "version not found", connectErr.Message())
+	require.Len(t, connectErr.Details(), 1)
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	shared, ok := detail.(*sharedv1.Error)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusNotFound), shared.GetCode())
+	assert.Equal(t, []string{"no such ref"}, shared.GetDetails())
+	assert.Equal(t, map[string]string{"RefName": "unknown ref"}, shared.GetNestedErrors())
+
+	// act: render the Connect error back as the shared.v1.Error JSON
+	var gwErr sharedv1.Error
+	require.NoError(t, protojson.Unmarshal(apierrors.MarshalJSON(t.Context(), connectErr), &gwErr))
+
+	// assert: the same error
+	assert.Equal(t, shared.GetCode(), gwErr.GetCode())
+	assert.Equal(t, shared.GetMessage(), gwErr.GetMessage())
+	assert.Equal(t, shared.GetDetails(), gwErr.GetDetails())
+	assert.Equal(t, shared.GetNestedErrors(), gwErr.GetNestedErrors())
+}
+
+func TestFromMapsCodes(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		err     error
+		code    connect.Code
+		status  int
+		message string
+	}{
+		{context.DeadlineExceeded, connect.CodeDeadlineExceeded, http.StatusGatewayTimeout, context.DeadlineExceeded.Error()},
+		{context.Canceled, connect.CodeCanceled, 499, context.Canceled.Error()},
+		{connect.NewError(connect.CodeUnauthenticated, errors.New("no token")), connect.CodeUnauthenticated, http.StatusUnauthorized, "no token"},
+		{io.ErrUnexpectedEOF, connect.CodeInternal, http.StatusInternalServerError, "internal error"},
+	} {
+		e := apierrors.From(tc.err)
+		assert.Equal(t, tc.code, e.Code(), tc.err)
+		assert.Equal(t, tc.status, e.HTTPStatus(), tc.err)
+		assert.Equal(t, tc.message, e.Message(), tc.err)
+	}
+}
+
+//nolint:paralleltest // changes the default logger
+func TestWriteHTTPErrorStackAtDebugLevel(t *testing.T) {
+	// arrange: the internal error
+	err := apierrors.Internal(io.ErrUnexpectedEOF)
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	write := func(level slog.Level) *sharedv1.Error {
+		defer slog.SetDefault(slog.Default())
+		slog.SetDefault(log.NewLogger(log.NewJSONHandler(io.Discard, level)))
+		w := httptest.NewRecorder()
+		apierrors.WriteHTTPError(w, req, err)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var shared sharedv1.Error
+		require.NoError(t, protojson.Unmarshal(w.Body.Bytes(), &shared))
+		return &shared
+	}
+
+	// act, assert: the cause and the stack are not exposed at info level
+	shared := write(slog.LevelInfo)
+	assert.Equal(t, "internal error", shared.GetMessage())
+	assert.Empty(t, shared.GetDetails())
+	assert.Empty(t, shared.GetStack())
+
+	// act, assert: the cause and the stack are rendered at debug level
+	shared = write(slog.LevelDebug)
+	assert.Equal(t, []string{io.ErrUnexpectedEOF.Error()}, shared.GetDetails())
+	assert.Contains(t, string(shared.GetStack()), t.Name())
+}
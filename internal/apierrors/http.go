@@ -0,0 +1,62 @@
+package apierrors
+
+import (
+	"context"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// httpStatus maps the error codes to the HTTP status codes, the same as the Connect protocol.
+var httpStatus = map[connect.Code]int{
+	connect.CodeCanceled:           499,
+	connect.CodeUnknown:            http.StatusInternalServerError,
+	connect.CodeInvalidArgument:    http.StatusBadRequest,
+	connect.CodeDeadlineExceeded:   http.StatusGatewayTimeout,
+	connect.CodeNotFound:           http.StatusNotFound,
+	connect.CodeAlreadyExists:      http.StatusConflict,
+	connect.CodePermissionDenied:   http.StatusForbidden,
+	connect.CodeResourceExhausted:  http.StatusTooManyRequests,
+	connect.CodeFailedPrecondition: http.StatusBadRequest,
+	connect.CodeAborted:            http.StatusConflict,
+	connect.CodeOutOfRange:         http.StatusBadRequest,
+	connect.CodeUnimplemented:      http.StatusNotImplemented,
+	connect.CodeInternal:           http.StatusInternalServerError,
+	connect.CodeUnavailable:        http.StatusServiceUnavailable,
+	connect.CodeDataLoss:           http.StatusInternalServerError,
+	connect.CodeUnauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatus returns the HTTP status code of the error code.
+func HTTPStatus(code connect.Code) int {
+	if status, ok := httpStatus[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteHTTPError writes the error response. Connect, gRPC and gRPC-Web requests get the Connect error,
+// see Error.ConnectError, other requests get the shared.v1.Error JSON, see Error.Proto.
+// Connect unary requests are recognized by the Connect-Protocol-Version header.
+func WriteHTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	if errorWriter := connect.NewErrorWriter(connect.WithRequireConnectProtocolHeader()); errorWriter.IsSupported(r) {
+		_ = errorWriter.Write(w, r, e.ConnectError(r.Context()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.HTTPStatus())
+	_, _ = w.Write(MarshalJSON(r.Context(), e))
+}
+
+// MarshalJSON returns the shared.v1.Error JSON of the error, see Error.Proto.
+func MarshalJSON(ctx context.Context, err error) []byte {
+	e := From(err)
+	data, marshalErr := protojson.Marshal(e.Proto(ctx))
+	if marshalErr != nil {
+		return []byte(e.Error())
+	}
+	return data
+}
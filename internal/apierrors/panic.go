@@ -0,0 +1,41 @@
+package apierrors
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxPanicStackSize = 64 << 10
+
+// PanicError is the recovered panic.
+type PanicError struct {
+	panic any
+	stack []byte
+}
+
+// NewPanicError returns the recovered panic error with the current goroutine stack, call it from the deferred function.
+func NewPanicError(p any) *PanicError {
+	stack := make([]byte, maxPanicStackSize)
+	stack = stack[:runtime.Stack(stack, false)]
+	return &PanicError{panic: p, stack: stack}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic caught: %v", e.panic)
+}
+
+func (e *PanicError) Stack() string {
+	return string(e.stack)
+}
+
+// InternalError returns the panic as the internal error with the panic stack, and the trace ID detail if traced.
+func (e *PanicError) InternalError(ctx context.Context) *Error {
+	options := []Option{WithStack(e.stack)}
+	if s := trace.SpanContextFromContext(ctx); s.IsValid() {
+		options = append(options, WithDetails("trace_id: "+s.TraceID().String()))
+	}
+	return Internal(e, options...)
+}
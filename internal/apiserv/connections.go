@@ -0,0 +1,60 @@
+package apiserv
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/leonardinius/go-service-template/internal/insights"
+)
+
+// Connections counts the open server connections, see TrackConnections.
+type Connections struct {
+	open atomic.Int64
+	// openGauge is the http_server_open_connections metric, shared by the servers of the same registry.
+	openGauge prometheus.Gauge
+	// closed is the http_server_shutdown_closed_connections_total metric, see Shutdown.
+	closed prometheus.Counter
+}
+
+// TrackConnections counts the server connections and registers the http_server_open_connections and
+// http_server_shutdown_closed_connections_total metrics. It chains the server ConnState hook, call it before serving.
+// The metrics are shared by the servers of the same registry, the connections of every server are counted.
+func TrackConnections(ctx context.Context, srv *http.Server) *Connections {
+	registerer := insights.RegistrerFromContext(ctx)
+	// the conflicting metrics of the registry are kept, the connections are not exported then
+	openGauge, _ := insights.RegisterCollector(registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_server_open_connections",
+		Help: "Number of open HTTP server connections.",
+	}))
+	closed, _ := insights.RegisterCollector(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_server_shutdown_closed_connections_total",
+		Help: "Number of HTTP server connections still active when the shutdown timeout expired.",
+	}))
+	conns := &Connections{openGauge: openGauge, closed: closed}
+
+	next := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			conns.open.Add(1)
+			conns.openGauge.Inc()
+		case http.StateClosed, http.StateHijacked:
+			conns.open.Add(-1)
+			conns.openGauge.Dec()
+		case http.StateActive, http.StateIdle:
+		}
+		if next != nil {
+			next(conn, state)
+		}
+	}
+	return conns
+}
+
+// Open returns the number of open connections, hijacked connections are not counted.
+func (c *Connections) Open() int64 {
+	return c.open.Load()
+}
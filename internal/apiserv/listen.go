@@ -2,6 +2,7 @@ package apiserv
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 )
@@ -34,9 +35,14 @@ func ListenAndServe(parentContext context.Context, srv *http.Server, options ...
 type serveListenConfigOptions struct {
 	isSsl             bool
 	certFile, keyFile string
+	tlsConfig         *tls.Config
 }
 
 func (cfg *serveListenConfigOptions) ListenAndServe(srv *http.Server) (err error) {
+	if cfg.tlsConfig != nil {
+		srv.TLSConfig = cfg.tlsConfig
+		return srv.ListenAndServeTLS("", "")
+	}
 	if cfg.isSsl {
 		return srv.ListenAndServeTLS(cfg.certFile, cfg.keyFile)
 	}
@@ -89,3 +95,23 @@ func WithListenSSL(certFile, keyFile string) ListenOption {
 		srv.keyFile = keyFile
 	})
 }
+
+// WithListenTLS returns an Option that configures the server with the TLS config.
+//
+// The config parameter must provide the server certificate, e.g. see NewServerTLSConfig.
+//
+// Example usage:
+//
+//	tlsConfig, err := NewServerTLSConfig(ctx, "cert.pem", "key.pem", "ca.pem", tls.RequireAndVerifyClientCert)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = ListenAndServe(ctx, srv, WithListenTLS(tlsConfig))
+//
+// The server will serve HTTPS, the client certificates are verified as configured.
+// WithListenTLS takes precedence over WithListenSSL.
+func WithListenTLS(config *tls.Config) ListenOption {
+	return listenOptionFunc(func(srv *serveListenConfigOptions) {
+		srv.tlsConfig = config
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
@@ -70,11 +71,14 @@ func BuildHTTPMux(ctx context.Context, options ...Option) http.Handler {
 	var handler http.Handler = mux
 	// Please note the order of middleware registration is important.
 	// Execution is the reverse of the registration order.
+	handler = NewClientCertHandlerMiddleware(handler)
 	handler = NewRecoveryHandlerMiddleware(handler, logger)
+	handler = NewCORSHandlerMiddleware(handler, options...)
 	handler = insights.NewAddXHeadersHandlerMiddleware(handler)
 	handler = NewLogHandlerMiddleware(handler, logger, level, "http")
 	handler = insights.NewTraceparentHandlerMiddleware(handler)
 	handler = insights.NewOtelHandlerMiddleware(handler, "http")
+	handler = insights.NewPanicsMetricsHandlerMiddleware(handler, ctx)
 	handler = insights.NewMetricsHandlerMiddleware(handler, ctx, "http")
 	return handler
 }
@@ -128,6 +132,7 @@ type serverConfigOptions struct {
 	middlewareLogLevel slog.Level
 	address            string
 	routes             []Route
+	cors               corsOptions
 }
 
 type Option interface {
@@ -145,6 +150,7 @@ func initializeOptions(opts []Option) *serverConfigOptions {
 		logger:             slog.Default(),
 		middlewareLogLevel: slog.LevelInfo,
 		address:            "",
+		cors:               defaultCORSOptions(),
 	}
 
 	for _, opt := range opts {
@@ -247,3 +253,65 @@ func WithRoutes(routes ...Route) Option {
 		srv.routes = append(srv.routes, routes...)
 	})
 }
+
+// WithCORSAllowedOrigins returns an Option that configures the origins allowed to make the cross-origin requests.
+//
+// The origin "*" allows any origin, the single wildcard matches the rest of the origin, e.g. "https://*.example.com".
+// Without the allowed origins the cross-origin requests are not handled, see NewCORSHandlerMiddleware.
+//
+// Example usage:
+//
+//	opts := []Option{
+//	  WithCORSAllowedOrigins("https://app.example.com", "http://localhost:*"),
+//	}
+//	server := NewServer(opts...)
+//
+// The server will answer the preflight requests of the allowed origins.
+func WithCORSAllowedOrigins(origins ...string) Option {
+	return optionFunc(func(srv *serverConfigOptions) {
+		srv.cors.allowedOrigins = origins
+	})
+}
+
+// WithCORSAllowedMethods returns an Option that configures the methods allowed in the cross-origin requests.
+//
+// The methods replace the defaults, see CORSDefaultAllowedMethods.
+func WithCORSAllowedMethods(methods ...string) Option {
+	return optionFunc(func(srv *serverConfigOptions) {
+		srv.cors.allowedMethods = methods
+	})
+}
+
+// WithCORSAllowedHeaders returns an Option that configures the request headers allowed in the cross-origin requests.
+//
+// The headers replace the defaults, see CORSDefaultAllowedHeaders.
+//
+// Example usage:
+//
+//	opts := []Option{
+//	  WithCORSAllowedHeaders(append(CORSDefaultAllowedHeaders(), "Authorization")...),
+//	}
+//	server := NewServer(opts...)
+func WithCORSAllowedHeaders(headers ...string) Option {
+	return optionFunc(func(srv *serverConfigOptions) {
+		srv.cors.allowedHeaders = headers
+	})
+}
+
+// WithCORSExposedHeaders returns an Option that configures the response headers exposed to the cross-origin requests.
+//
+// The headers replace the defaults, see CORSDefaultExposedHeaders.
+func WithCORSExposedHeaders(headers ...string) Option {
+	return optionFunc(func(srv *serverConfigOptions) {
+		srv.cors.exposedHeaders = headers
+	})
+}
+
+// WithCORSMaxAge returns an Option that configures how long the browsers cache the preflight responses.
+//
+// The default is DefaultCORSMaxAge.
+func WithCORSMaxAge(maxAge time.Duration) Option {
+	return optionFunc(func(srv *serverConfigOptions) {
+		srv.cors.maxAge = maxAge
+	})
+}
@@ -0,0 +1,33 @@
+package apiserv
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"net/http"
+)
+
+type clientSubjectKey struct{}
+
+// NewClientCertHandlerMiddleware adds the verified client certificate subject to the request context,
+// see ClientSubjectFromContext. The unverified client certificates are ignored.
+func NewClientCertHandlerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			subject := r.TLS.VerifiedChains[0][0].Subject
+			r = r.WithContext(ContextWithClientSubject(r.Context(), subject))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ContextWithClientSubject returns a new context with the given client certificate subject.
+func ContextWithClientSubject(ctx context.Context, subject pkix.Name) context.Context {
+	return context.WithValue(ctx, clientSubjectKey{}, subject)
+}
+
+// ClientSubjectFromContext returns the verified client certificate subject, e.g. to authorize the request.
+// It returns false if the client certificate was not verified.
+func ClientSubjectFromContext(ctx context.Context) (pkix.Name, bool) {
+	subject, ok := ctx.Value(clientSubjectKey{}).(pkix.Name)
+	return subject, ok
+}
@@ -0,0 +1,144 @@
+package apiserv
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCORSMaxAge is the default preflight response cache duration, the browsers cap it at 2 hours.
+const DefaultCORSMaxAge = 2 * time.Hour
+
+// CORSDefaultAllowedMethods returns the methods used by the Connect and gRPC-web clients:
+// GET for the side-effect free Connect procedures, POST for the rest.
+func CORSDefaultAllowedMethods() []string {
+	return []string{http.MethodGet, http.MethodPost}
+}
+
+// CORSDefaultAllowedHeaders returns the request headers sent by the Connect and gRPC-web clients.
+func CORSDefaultAllowedHeaders() []string {
+	return []string{
+		"Content-Type",
+		"Connect-Protocol-Version",
+		"Connect-Timeout-Ms",
+		"Grpc-Timeout",
+		"X-Grpc-Web",
+		"X-User-Agent",
+	}
+}
+
+// CORSDefaultExposedHeaders returns the response headers read by the Connect and gRPC-web clients,
+// and the X-Trace-Id header, see insights.NewAddXHeadersHandlerMiddleware.
+func CORSDefaultExposedHeaders() []string {
+	return []string{
+		"Grpc-Status",
+		"Grpc-Message",
+		"Grpc-Status-Details-Bin",
+		"X-Trace-Id",
+	}
+}
+
+type corsOptions struct {
+	allowedOrigins []string
+	allowedMethods []string
+	allowedHeaders []string
+	exposedHeaders []string
+	maxAge         time.Duration
+}
+
+func defaultCORSOptions() corsOptions {
+	return corsOptions{
+		allowedMethods: CORSDefaultAllowedMethods(),
+		allowedHeaders: CORSDefaultAllowedHeaders(),
+		exposedHeaders: CORSDefaultExposedHeaders(),
+		maxAge:         DefaultCORSMaxAge,
+	}
+}
+
+// NewCORSHandlerMiddleware handles the cross-origin requests of the allowed origins, see WithCORSAllowedOrigins.
+// The preflight requests are answered, the other requests get the allowed origin and the exposed headers.
+// Without the allowed origins the middleware is a no-op, the browsers deny the cross-origin requests.
+func NewCORSHandlerMiddleware(next http.Handler, options ...Option) http.Handler {
+	cfg := initializeOptions(options).cors
+	if len(cfg.allowedOrigins) == 0 {
+		return next
+	}
+
+	allowedMethods := strings.Join(cfg.allowedMethods, ", ")
+	exposedHeaders := strings.Join(cfg.exposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.maxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		header.Add("Vary", "Origin")
+
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && requestMethod != "" {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			requestHeaders := r.Header.Values("Access-Control-Request-Headers")
+			if cfg.allowsOrigin(origin) && containsFold(cfg.allowedMethods, requestMethod) && cfg.allowsHeaders(requestHeaders) {
+				header.Set("Access-Control-Allow-Origin", origin)
+				header.Set("Access-Control-Allow-Methods", allowedMethods)
+				if len(requestHeaders) > 0 {
+					header.Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+				}
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if cfg.allowsOrigin(origin) {
+			header.Set("Access-Control-Allow-Origin", origin)
+			if exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowsOrigin reports whether the origin matches any allowed origin. The allowed origin "*" matches any origin,
+// the single wildcard matches the rest of the origin, e.g. "https://*.example.com" matches "https://app.example.com".
+func (cfg *corsOptions) allowsOrigin(origin string) bool {
+	for _, allowed := range cfg.allowedOrigins {
+		prefix, suffix, wildcard := strings.Cut(allowed, "*")
+		if !wildcard {
+			if strings.EqualFold(allowed, origin) {
+				return true
+			}
+			continue
+		}
+		if len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+			strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether all the preflight request headers are allowed.
+// The header values are comma-separated lists, see Access-Control-Request-Headers.
+func (cfg *corsOptions) allowsHeaders(requestHeaders []string) bool {
+	for _, value := range requestHeaders {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !containsFold(cfg.allowedHeaders, name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
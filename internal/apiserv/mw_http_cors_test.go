@@ -0,0 +1,94 @@
+package apiserv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/leonardinius/go-service-template/internal/apiserv"
+)
+
+func TestNewCORSHandlerMiddleware(t *testing.T) {
+	t.Parallel()
+	// arrange: the handler allowing the example.com subdomains
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	middleware := apiserv.NewCORSHandlerMiddleware(handler, apiserv.WithCORSAllowedOrigins("https://*.example.com"))
+
+	for _, tc := range []struct {
+		name          string
+		method        string
+		header        http.Header
+		statusCode    int
+		allowOrigin   string
+		allowHeaders  string
+		exposeHeaders string
+	}{
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.example.com"},
+				"Access-Control-Request-Method":  {http.MethodPost},
+				"Access-Control-Request-Headers": {"content-type,connect-protocol-version"},
+			},
+			statusCode:   http.StatusNoContent,
+			allowOrigin:  "https://app.example.com",
+			allowHeaders: "content-type,connect-protocol-version",
+		},
+		{
+			name:   "preflight of not allowed header",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.example.com"},
+				"Access-Control-Request-Method":  {http.MethodPost},
+				"Access-Control-Request-Headers": {"Authorization"},
+			},
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:   "preflight of not allowed origin",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://example.com"},
+				"Access-Control-Request-Method": {http.MethodPost},
+			},
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:          "request",
+			method:        http.MethodPost,
+			header:        http.Header{"Origin": {"https://app.example.com"}},
+			statusCode:    http.StatusTeapot,
+			allowOrigin:   "https://app.example.com",
+			exposeHeaders: "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin, X-Trace-Id",
+		},
+		{
+			name:       "same origin request",
+			method:     http.MethodOptions,
+			statusCode: http.StatusTeapot,
+		},
+	} {
+		// act
+		req := httptest.NewRequest(tc.method, "http://api.example.com/version.v1.VersionService/GetVersion", nil)
+		req.Header = tc.header
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+
+		// assert
+		assert.Equal(t, tc.statusCode, w.Code, tc.name)
+		assert.Equal(t, tc.allowOrigin, w.Header().Get("Access-Control-Allow-Origin"), tc.name)
+		assert.Equal(t, tc.allowHeaders, w.Header().Get("Access-Control-Allow-Headers"), tc.name)
+		assert.Equal(t, tc.exposeHeaders, w.Header().Get("Access-Control-Expose-Headers"), tc.name)
+		if tc.allowHeaders != "" {
+			assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"), tc.name)
+			assert.Equal(t, "7200", w.Header().Get("Access-Control-Max-Age"), tc.name)
+		}
+	}
+}
@@ -2,43 +2,30 @@ package apiserv
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"net/http"
-	"runtime"
+
+	"github.com/leonardinius/go-service-template/internal/apierrors"
+	"github.com/leonardinius/go-service-template/internal/insights/panicmetrics"
 )
 
+// NewRecoveryHandlerMiddleware recovers the handler panics, logs and counts them, see panicmetrics.Record.
+// The response is the internal error in the request protocol, see apierrors.WriteHTTPError.
+// Connect handlers recover the panics with the Connect interceptor, see servicerecover.
 func NewRecoveryHandlerMiddleware(handler http.Handler, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func(ctx context.Context) {
 			if err := recover(); err != nil {
-				err := newPanickedErrorFromStack(err)
+				err := apierrors.NewPanicError(err)
 				logger.LogAttrs(ctx, slog.LevelError, "recovered from panic",
+					slog.String("procedure", r.URL.Path),
 					slog.String("error", err.Error()),
 					slog.String("stack", err.Stack()),
 				)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				panicmetrics.Record(ctx, r.URL.Path)
+				apierrors.WriteHTTPError(w, r, err.InternalError(ctx))
 			}
 		}(r.Context())
 		handler.ServeHTTP(w, r)
 	})
 }
-
-func newPanickedErrorFromStack(p any) *PanicError {
-	stack := make([]byte, 64<<10)
-	stack = stack[:runtime.Stack(stack, false)]
-	return &PanicError{panic: p, stack: stack}
-}
-
-type PanicError struct {
-	panic any
-	stack []byte
-}
-
-func (e *PanicError) Error() string {
-	return fmt.Sprintf("panic caught: %v", e.panic)
-}
-
-func (e *PanicError) Stack() string {
-	return string(e.stack)
-}
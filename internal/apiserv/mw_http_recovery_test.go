@@ -2,6 +2,7 @@ package apiserv_test
 
 import (
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -10,9 +11,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/leonardinius/go-service-template/internal/apiserv"
 	"github.com/leonardinius/go-service-template/internal/log"
+
+	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
 )
 
 func TestNewRecoveryHandlerMiddlewareSmokeTest(t *testing.T) {
@@ -34,9 +38,14 @@ func TestNewRecoveryHandlerMiddlewareSmokeTest(t *testing.T) {
 	w := httptest.NewRecorder()
 	middleware.ServeHTTP(w, req)
 
-	// assert: the response should be 500
+	// assert: the response should be 500 with the internal error, the panic is not exposed
 	assert.Equal(t, 500, w.Code)
-	assert.Equal(t, "Internal Server Error\n", w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var respErr sharedv1.Error
+	require.NoError(t, protojson.Unmarshal(w.Body.Bytes(), &respErr))
+	assert.Equal(t, int32(500), respErr.GetCode())
+	assert.Equal(t, "internal error", respErr.GetMessage())
+	assert.Empty(t, respErr.GetStack())
 
 	// assert: the log message should contain the panic details
 	var message map[string]interface{}
@@ -46,3 +55,24 @@ func TestNewRecoveryHandlerMiddlewareSmokeTest(t *testing.T) {
 	assert.Equal(t, "panic caught: hello, panic!", message["error"])
 	assert.Contains(t, message["stack"], t.Name())
 }
+
+func TestNewRecoveryHandlerMiddlewareGrpc(t *testing.T) {
+	t.Parallel()
+	// arrange: an handler that panics
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		panic("hello, panic!")
+	})
+	middleware := apiserv.NewRecoveryHandlerMiddleware(handler, log.NewLogger(log.NewJSONHandler(io.Discard, slog.LevelInfo)))
+
+	// act: make a gRPC request to the handler
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/test.v1.TestService/Panic", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/grpc")
+	w := httptest.NewRecorder()
+	middleware.ServeHTTP(w, req)
+
+	// assert: the gRPC internal error status
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/grpc", w.Header().Get("Content-Type"))
+	assert.Equal(t, "13", w.Header().Get("Grpc-Status"))
+	assert.Equal(t, "internal error", w.Header().Get("Grpc-Message"))
+}
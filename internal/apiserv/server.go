@@ -9,12 +9,17 @@ import (
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
+	"github.com/leonardinius/go-service-template/internal/health"
 	"github.com/leonardinius/go-service-template/internal/insights"
 )
 
 const (
 	HeaderReadTimeout = 3 * time.Second
 	MetricsRoutePath  = "/metrics"
+	// HealthzRoutePath is the liveness probe path, see health.Registry.Liveness.
+	HealthzRoutePath = "/healthz"
+	// ReadyzRoutePath is the readiness probe path, see health.Registry.Readiness.
+	ReadyzRoutePath = "/readyz"
 )
 
 func NewServer(ctx context.Context, address string, options ...Option) (*http.Server, error) {
@@ -31,11 +36,16 @@ func NewServer(ctx context.Context, address string, options ...Option) (*http.Se
 	return &srv, nil
 }
 
-func NewDefaultServer(ctx context.Context, address string, routes ...Route) (*http.Server, error) {
-	return NewServer(ctx, address,
+// NewDefaultServer returns the server of the routes, the metrics and the probes of the context health registry,
+// see health.RegistryFromContext. The options are applied after the defaults, e.g. WithCORSAllowedOrigins.
+func NewDefaultServer(ctx context.Context, address string, routes []Route, options ...Option) (*http.Server, error) {
+	registry := health.RegistryFromContext(ctx)
+	return NewServer(ctx, address, append([]Option{
 		WithLogger(slog.Default()),
 		WithMiddlewareLogLevel(slog.LevelDebug),
 		WithRoutes(routes...),
 		WithRoute("GET "+MetricsRoutePath, insights.NewMetricsHTTPHandler(ctx)),
-	)
+		WithRoute("GET "+HealthzRoutePath, registry.LivenessHandler()),
+		WithRoute("GET "+ReadyzRoutePath, registry.ReadinessHandler()),
+	}, options...)...)
 }
@@ -0,0 +1,106 @@
+package apiserv
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultPreStopDelay is the default lame duck delay, the server shuts down immediately.
+	DefaultPreStopDelay = 0
+	// DefaultShutdownTimeout is the default deadline to complete the active requests on shutdown.
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// Shutdown gracefully shuts down the server in the lame duck sequence:
+//
+//  1. the server keeps serving during the pre-stop delay, so the load balancers notice the failing
+//     readiness probe and stop sending new requests, see WithPreStopDelay and ReadyzRoutePath;
+//  2. the server stops listening and waits for the active requests to complete, see WithShutdownTimeout;
+//  3. once the shutdown timeout expires the remaining connections are closed, their number is logged
+//     and counted in the http_server_shutdown_closed_connections_total metric.
+//
+// Example usage:
+//
+//	conns := apiserv.TrackConnections(ctx, srv)
+//	go func() { _ = apiserv.ListenAndServe(context.WithoutCancel(ctx), srv) }()
+//	<-ctx.Done()
+//	err := apiserv.Shutdown(context.WithoutCancel(ctx), srv, conns, apiserv.WithPreStopDelay(5*time.Second))
+func Shutdown(ctx context.Context, srv *http.Server, conns *Connections, options ...ShutdownOption) error {
+	cfg := initializeShutdownOptions(options)
+
+	if cfg.preStopDelay > 0 {
+		slog.LogAttrs(ctx, slog.LevelInfo, "http server is lame duck, waiting before shutdown",
+			slog.String("address", srv.Addr),
+			slog.Duration("pre_stop_delay", cfg.preStopDelay),
+			slog.Int64("connections", conns.Open()))
+		timer := time.NewTimer(cfg.preStopDelay)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	active := conns.Open()
+	conns.closed.Add(float64(active))
+	slog.LogAttrs(ctx, slog.LevelWarn, "http server shutdown timed out, closing active connections",
+		slog.String("address", srv.Addr),
+		slog.Duration("shutdown_timeout", cfg.shutdownTimeout),
+		slog.Int64("connections", active))
+	return srv.Close()
+}
+
+type shutdownConfigOptions struct {
+	preStopDelay    time.Duration
+	shutdownTimeout time.Duration
+}
+
+// ShutdownOption is an interface that represents a configuration option of Shutdown.
+type ShutdownOption interface {
+	apply(option *shutdownConfigOptions)
+}
+
+type shutdownOptionFunc func(*shutdownConfigOptions)
+
+func (f shutdownOptionFunc) apply(cfg *shutdownConfigOptions) {
+	f(cfg)
+}
+
+func initializeShutdownOptions(options []ShutdownOption) *shutdownConfigOptions {
+	cfg := &shutdownConfigOptions{
+		preStopDelay:    DefaultPreStopDelay,
+		shutdownTimeout: DefaultShutdownTimeout,
+	}
+	for _, option := range options {
+		option.apply(cfg)
+	}
+	return cfg
+}
+
+// WithPreStopDelay returns a ShutdownOption that configures the lame duck delay before the server stops listening.
+//
+// The readiness probe is expected to fail during the delay, see ReadyzRoutePath.
+func WithPreStopDelay(delay time.Duration) ShutdownOption {
+	return shutdownOptionFunc(func(cfg *shutdownConfigOptions) {
+		cfg.preStopDelay = delay
+	})
+}
+
+// WithShutdownTimeout returns a ShutdownOption that configures the deadline to complete the active requests,
+// the remaining connections are closed afterwards.
+func WithShutdownTimeout(timeout time.Duration) ShutdownOption {
+	return shutdownOptionFunc(func(cfg *shutdownConfigOptions) {
+		cfg.shutdownTimeout = timeout
+	})
+}
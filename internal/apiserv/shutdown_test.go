@@ -0,0 +1,121 @@
+package apiserv_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/health"
+	"github.com/leonardinius/go-service-template/internal/insights"
+)
+
+func TestShutdownClosesActiveConnectionsAfterTimeout(t *testing.T) {
+	t.Parallel()
+	// arrange: the server with the request which never completes
+	registry := insights.NewMetricsRegistry()
+	ctx := insights.ContextWithRegistry(t.Context(), registry)
+	started := make(chan struct{})
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	conns := apiserv.TrackConnections(ctx, srv.Config)
+	srv.Start()
+	defer srv.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := get(ctx, srv, "/")
+		errCh <- err
+	}()
+	<-started
+	assert.Equal(t, int64(1), conns.Open())
+
+	// act: shut down with the short timeout
+	err := apiserv.Shutdown(ctx, srv.Config, conns, apiserv.WithShutdownTimeout(100*time.Millisecond))
+
+	// assert: the active connection is closed and counted
+	require.NoError(t, err)
+	require.Error(t, <-errCh)
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP http_server_shutdown_closed_connections_total Number of HTTP server connections still active when the shutdown timeout expired.
+# TYPE http_server_shutdown_closed_connections_total counter
+http_server_shutdown_closed_connections_total 1
+`), "http_server_shutdown_closed_connections_total"))
+}
+
+func TestShutdownServesDuringPreStopDelay(t *testing.T) {
+	t.Parallel()
+	// arrange: the ready server
+	registry := health.NewRegistry()
+	registry.SetServing(health.StatusServing)
+	ctx := insights.ContextWithRegistry(t.Context(), insights.NewMetricsRegistry())
+	srv := httptest.NewUnstartedServer(registry.ReadinessHandler())
+	conns := apiserv.TrackConnections(ctx, srv.Config)
+	srv.Start()
+	defer srv.Close()
+	status, err := get(ctx, srv, apiserv.ReadyzRoutePath)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	// act: the server is not serving, the shutdown is delayed
+	registry.SetServing(health.StatusNotServing)
+	done := make(chan error, 1)
+	go func() {
+		done <- apiserv.Shutdown(ctx, srv.Config, conns, apiserv.WithPreStopDelay(300*time.Millisecond))
+	}()
+
+	// assert: the readiness probe fails, but the requests are still served until the delay expires
+	status, err = get(ctx, srv, apiserv.ReadyzRoutePath)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	require.NoError(t, <-done)
+	_, err = get(ctx, srv, apiserv.ReadyzRoutePath)
+	require.Error(t, err)
+}
+
+func TestTrackConnectionsCountsServersOfSameRegistry(t *testing.T) {
+	t.Parallel()
+	// arrange: the servers of the same registry
+	registry := insights.NewMetricsRegistry()
+	ctx := insights.ContextWithRegistry(t.Context(), registry)
+	for range 2 {
+		srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+		apiserv.TrackConnections(ctx, srv.Config)
+		srv.Start()
+		t.Cleanup(srv.Close)
+
+		// act: the keep-alive connection stays open
+		_, err := get(ctx, srv, "/")
+		require.NoError(t, err)
+	}
+
+	// assert: the open connections of both servers are counted
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP http_server_open_connections Number of open HTTP server connections.
+# TYPE http_server_open_connections gauge
+http_server_open_connections 2
+`), "http_server_open_connections"))
+}
+
+func get(ctx context.Context, srv *httptest.Server, path string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
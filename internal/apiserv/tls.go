@@ -0,0 +1,184 @@
+package apiserv
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/leonardinius/go-service-template/internal/devcerts"
+)
+
+// certReloadInterval is the interval of the certificate and key files modification time checks.
+const certReloadInterval = time.Second
+
+var errNoClientCACerts = errors.New("no client CA certificates found")
+
+// clientAuthPolicies are the --tls-client-auth policy names, see ParseClientAuth.
+var clientAuthPolicies = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// ClientAuthPolicies returns the client authentication policy names, see ParseClientAuth.
+func ClientAuthPolicies() []string {
+	policies := make([]string, 0, len(clientAuthPolicies))
+	for policy := range clientAuthPolicies {
+		policies = append(policies, policy)
+	}
+	sort.Strings(policies)
+	return policies
+}
+
+// ParseClientAuth returns the client authentication of the policy name, see ClientAuthPolicies.
+func ParseClientAuth(policy string) (tls.ClientAuthType, error) {
+	clientAuth, ok := clientAuthPolicies[policy]
+	if !ok {
+		return tls.NoClientCert, fmt.Errorf("unknown client auth policy %q, expected one of: %s",
+			policy, strings.Join(ClientAuthPolicies(), ", "))
+	}
+	return clientAuth, nil
+}
+
+// NewServerTLSConfig returns the server TLS config of the certificate and key files.
+//
+// The certificate and key are reloaded in background once the files change, until the ctx is done,
+// so the renewed certificate is served to the new connections without a restart.
+// The reload error is logged, the loaded certificate is kept.
+//
+// The clientCAFile is optional, the client certificates are verified against its certificates,
+// the clientAuth policy tells whether the client certificate is requested and required.
+func NewServerTLSConfig(
+	ctx context.Context,
+	certFile, keyFile, clientCAFile string,
+	clientAuth tls.ClientAuthType,
+) (*tls.Config, error) {
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := reloader.reload(reloader.filesModTime()); err != nil {
+		return nil, err
+	}
+	config, err := newServerTLSConfig(reloader.getCertificate, clientCAFile, clientAuth)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.watch(ctx, certReloadInterval)
+	return config, nil
+}
+
+// NewDevServerTLSConfig returns the server TLS config of the development certificate for the SANs,
+// see devcerts.DefaultSANs. The certificate and its CA are generated in memory, the clients do not trust it
+// unless they skip the verification. The clientCAFile and the clientAuth are the same as of NewServerTLSConfig.
+func NewDevServerTLSConfig(sans []string, clientCAFile string, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	ca, err := devcerts.NewCA("development CA", devcerts.DefaultValidFor)
+	if err != nil {
+		return nil, err
+	}
+	server, err := ca.IssueServer(sans, devcerts.DefaultValidFor)
+	if err != nil {
+		return nil, err
+	}
+	cert := server.TLSCertificate()
+	return newServerTLSConfig(func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return &cert, nil
+	}, clientCAFile, clientAuth)
+}
+
+func newServerTLSConfig(
+	getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error),
+	clientCAFile string,
+	clientAuth tls.ClientAuthType,
+) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: getCertificate,
+		ClientAuth:     clientAuth,
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: %w", clientCAFile, errNoClientCACerts)
+		}
+	}
+	return config, nil
+}
+
+// certReloader serves the certificate and reloads it once the certificate or key file modification time changes.
+// The handshakes read the loaded certificate only, the files are checked by watch.
+type certReloader struct {
+	certFile, keyFile string
+
+	cert atomic.Pointer[tls.Certificate]
+	// modTime is owned by the watch goroutine once the certificate is loaded.
+	modTime time.Time
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// watch checks the files every interval and reloads the changed certificate until the ctx is done.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime := r.filesModTime()
+		if modTime.Equal(r.modTime) {
+			continue
+		}
+		if err := r.reload(modTime); err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "tls certificate reload failed, serving the loaded certificate",
+				slog.String("cert", r.certFile),
+				slog.String("error", err.Error()))
+			// retry once the files change again
+			r.modTime = modTime
+			continue
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "tls certificate reloaded", slog.String("cert", r.certFile))
+	}
+}
+
+func (r *certReloader) reload(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert.Store(&cert)
+	r.modTime = modTime
+	return nil
+}
+
+// filesModTime returns the latest modification time of the certificate and key files.
+// The files are replaced one by one, the zero time is returned if either is missing.
+func (r *certReloader) filesModTime() time.Time {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,108 @@
+package apiserv_test
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/devcerts"
+)
+
+func TestServerTLSConfigReloadsCertificateAndVerifiesClient(t *testing.T) {
+	t.Parallel()
+	// arrange: the CA, the server and client certificates
+	dir := t.TempDir()
+	ca, err := devcerts.NewCA("test-ca", time.Hour)
+	require.NoError(t, err)
+	mustWrite(t, dir, "ca", ca)
+	mustWriteServer(t, ca, dir, "server-1")
+	client, err := ca.IssueClient("client-1", time.Hour)
+	require.NoError(t, err)
+	// arrange: the mutual TLS server which replies the client subject
+	tlsConfig, err := apiserv.NewServerTLSConfig(t.Context(),
+		filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"), filepath.Join(dir, "ca.pem"),
+		tls.RequireAndVerifyClientCert)
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+	srv := &http.Server{
+		ReadHeaderTimeout: apiserv.HeaderReadTimeout,
+		Handler: apiserv.NewClientCertHandlerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, ok := apiserv.ClientSubjectFromContext(r.Context())
+			assert.True(t, ok)
+			_, _ = io.WriteString(w, subject.CommonName)
+		})),
+	}
+	go func() { _ = srv.Serve(listener) }()
+	defer func() { _ = srv.Close() }()
+	roots := ca.CertPool()
+	get := func(certs ...tls.Certificate) (string, string, error) {
+		httpClient := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12},
+			DisableKeepAlives: true,
+		}}
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://"+listener.Addr().String(), nil)
+		require.NoError(t, err)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return "", "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), resp.TLS.PeerCertificates[0].Subject.CommonName, err
+	}
+
+	// act, assert: the client subject is passed to the handler
+	subject, server, err := get(client.TLSCertificate())
+	require.NoError(t, err)
+	assert.Equal(t, "client-1", subject)
+	assert.Equal(t, "server-1", server)
+
+	// act, assert: the client without a certificate is rejected
+	_, _, err = get()
+	require.Error(t, err)
+
+	// act: renew the server certificate
+	mustWriteServer(t, ca, dir, "server-2")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "server.pem"), future, future))
+
+	// assert: the new connections are served the renewed certificate once it is reloaded
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		_, server, err := get(client.TLSCertificate())
+		require.NoError(c, err)
+		assert.Equal(c, "server-2", server)
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestParseClientAuth(t *testing.T) {
+	t.Parallel()
+	clientAuth, err := apiserv.ParseClientAuth("verify-if-given")
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, clientAuth)
+
+	_, err = apiserv.ParseClientAuth("always")
+	require.ErrorContains(t, err, "none, request, require, require-and-verify, verify-if-given")
+}
+
+// mustWriteServer writes the server.pem and server-key.pem files of the server certificate for 127.0.0.1.
+func mustWriteServer(t *testing.T, ca *devcerts.Cert, dir, commonName string) {
+	t.Helper()
+	server, err := ca.IssueServer([]string{commonName, "127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+	mustWrite(t, dir, "server", server)
+}
+
+func mustWrite(t *testing.T, dir, name string, cert *devcerts.Cert) {
+	t.Helper()
+	_, _, err := cert.WriteFiles(dir, name)
+	require.NoError(t, err)
+}
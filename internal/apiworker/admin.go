@@ -0,0 +1,147 @@
+package apiworker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/leonardinius/go-service-template/internal/apigen/version/v1/versionv1connect"
+	"github.com/leonardinius/go-service-template/internal/log"
+)
+
+// AdminSubjectPrefix is the prefix of the admin subjects, see WithAdmin.
+const AdminSubjectPrefix = "_ADMIN"
+
+// Admin commands, the last token of the admin subject.
+const (
+	// AdminStatus replies with the instance status.
+	AdminStatus = "STATUS"
+	// AdminVersion replies with the instance status and the version service response.
+	AdminVersion = "VERSION"
+	// AdminInFlight replies with the instance status, which has the number of requests being handled.
+	AdminInFlight = "INFLIGHT"
+	// AdminLogLevel changes the log level to the request body level, e.g. "debug", if not empty.
+	AdminLogLevel = "LOGLEVEL"
+	// AdminDrain stops receiving route requests, requests in flight are completed.
+	// The instance keeps answering admin requests until shut down.
+	AdminDrain = "DRAIN"
+)
+
+var (
+	errUnknownAdminCommand = errors.New("unknown admin command")
+	errAdminVersion        = errors.New("version service error")
+)
+
+// AdminReply is the JSON reply to the admin requests.
+type AdminReply struct {
+	Instance string          `json:"instance"`
+	Hostname string          `json:"hostname,omitempty"`
+	InFlight int64           `json:"in_flight"`
+	LogLevel string          `json:"log_level"`
+	Draining bool            `json:"draining"`
+	Version  json.RawMessage `json:"version,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// AdminSubject returns the subject of the admin command answered by every instance of the service,
+// e.g. "_ADMIN.<service>.VERSION". Set the instance ID to address a single instance,
+// e.g. "_ADMIN.<service>.<instance>.DRAIN". The subject prefix of the workers, see WithSubjectPrefix,
+// is prepended, e.g. "prod._ADMIN.<service>.VERSION", so the namespaces do not answer each other.
+func AdminSubject(prefix, service, instance, command string) string {
+	return joinSubject(prefix, AdminSubjectPrefix, microServiceName(service), instance, command)
+}
+
+// subscribeAdmin subscribes every instance, no queue group, to the admin subjects of the service.
+func (w *worker) subscribeAdmin(ctx context.Context) error {
+	subject := AdminSubject(w.config.subjects.Prefix, w.config.admin, "", ">")
+	_, err := w.natsCon.Subscribe(subject, func(msg *nats.Msg) {
+		reply := w.serveAdmin(ctx, msg)
+		if reply == nil {
+			// addressed to another instance
+			return
+		}
+		data, err := json.Marshal(reply)
+		if err == nil {
+			err = msg.Respond(data)
+		}
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "nats admin response error",
+				slog.String("subject", msg.Subject),
+				slog.String("error", err.Error()))
+		}
+	})
+	slog.LogAttrs(ctx, slog.LevelInfo, "nats admin subscribe",
+		slog.String("subject", subject),
+		slog.String("instance", w.instance))
+	return err
+}
+
+func (w *worker) serveAdmin(ctx context.Context, msg *nats.Msg) *AdminReply {
+	tokens := strings.Split(strings.TrimPrefix(msg.Subject, AdminSubject(w.config.subjects.Prefix, w.config.admin, "", "")+"."), ".")
+	command := tokens[len(tokens)-1]
+	if len(tokens) > 1 && tokens[0] != w.instance {
+		return nil
+	}
+
+	var err error
+	var version json.RawMessage
+	switch command {
+	case AdminStatus, AdminInFlight:
+	case AdminVersion:
+		version, err = w.adminVersion()
+	case AdminLogLevel:
+		err = adminLogLevel(msg.Data)
+	case AdminDrain:
+		slog.LogAttrs(ctx, slog.LevelWarn, "nats admin drain", slog.String("instance", w.instance))
+		err = w.drainRoutes()
+	default:
+		err = fmt.Errorf("%w: %q", errUnknownAdminCommand, command)
+	}
+
+	hostname, _ := os.Hostname()
+	reply := &AdminReply{
+		Instance: w.instance,
+		Hostname: hostname,
+		InFlight: w.inFlight.Load(),
+		LogLevel: log.Level().String(),
+		Draining: w.draining.Load(),
+		Version:  version,
+	}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	return reply
+}
+
+// adminVersion invokes the version service, so the reply is the same as the GetVersion response.
+func (w *worker) adminVersion() (json.RawMessage, error) {
+	req := httptest.NewRequest(http.MethodPost, versionv1connect.VersionServiceGetVersionProcedure, bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	w.handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errAdminVersion, resp.Body.String())
+	}
+	return resp.Body.Bytes(), nil
+}
+
+func adminLogLevel(data []byte) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText(bytes.TrimSpace(data)); err != nil {
+		return err
+	}
+	log.SetLevel(level)
+	return nil
+}
@@ -0,0 +1,111 @@
+package apiworker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// TimeoutHeader is the request timeout in milliseconds, as in the Connect protocol.
+	TimeoutHeader = "Connect-Timeout-Ms"
+	// GRPCTimeoutHeader is the request timeout in the gRPC protocol format, e.g. "100m".
+	GRPCTimeoutHeader = "Grpc-Timeout"
+)
+
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// requestTimeout returns the request timeout from the request headers, or defaultTimeout if there is none.
+// The result is capped by maxTimeout. Zero defaultTimeout and maxTimeout mean no timeout, the result is zero then.
+func requestTimeout(header http.Header, defaultTimeout, maxTimeout time.Duration) time.Duration {
+	timeout, ok := headerTimeout(header)
+	if !ok {
+		timeout = defaultTimeout
+	}
+	if maxTimeout > 0 && (timeout <= 0 || timeout > maxTimeout) {
+		timeout = maxTimeout
+	}
+	return max(timeout, 0)
+}
+
+func headerTimeout(header http.Header) (time.Duration, bool) {
+	if value := header.Get(TimeoutHeader); value != "" {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+	}
+	if value := header.Get(GRPCTimeoutHeader); len(value) > 1 {
+		unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+		if amount, err := strconv.ParseInt(value[:len(value)-1], 10, 64); ok && err == nil && amount > 0 {
+			return time.Duration(amount) * unit, true
+		}
+	}
+	return 0, false
+}
+
+// requestDeadline is the request context, which is cancelled with context.DeadlineExceeded cause
+// once the request timeout expires, see requestTimeout.
+//
+// The default timeout of the streamed response is the idle timeout instead: it is extended by every stream message,
+// so the long-lived streams are not cut off while they send messages. The max timeout still caps the stream.
+type requestDeadline struct {
+	ctx         context.Context //nolint:containedctx // the request context
+	cancel      context.CancelCauseFunc
+	stopTimeout context.CancelFunc
+	idle        time.Duration
+	timer       *time.Timer
+}
+
+func newRequestDeadline(
+	ctx context.Context,
+	header http.Header,
+	stream bool,
+	defaultTimeout, maxTimeout time.Duration,
+) *requestDeadline {
+	timeout := requestTimeout(header, defaultTimeout, maxTimeout)
+	d := &requestDeadline{}
+	if _, ok := headerTimeout(header); stream && !ok {
+		d.idle, timeout = timeout, maxTimeout
+	}
+
+	if timeout > 0 {
+		ctx, d.stopTimeout = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, d.stopTimeout = context.WithCancel(ctx)
+	}
+	d.ctx, d.cancel = context.WithCancelCause(ctx)
+	if d.idle > 0 {
+		d.timer = time.AfterFunc(d.idle, func() { d.cancel(context.DeadlineExceeded) })
+	}
+	return d
+}
+
+// extend restarts the idle timeout of the streamed response.
+func (d *requestDeadline) extend() {
+	if d.timer != nil {
+		d.timer.Reset(d.idle)
+	}
+}
+
+// exceeded reports whether the request context is done because the request timeout expired.
+func (d *requestDeadline) exceeded() bool {
+	return errors.Is(context.Cause(d.ctx), context.DeadlineExceeded)
+}
+
+// stop releases the request context.
+func (d *requestDeadline) stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.cancel(context.Canceled)
+	d.stopTimeout()
+}
@@ -0,0 +1,64 @@
+package apiworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// DefaultEmbeddedAddress is the embedded NATS.io server listen address.
+const DefaultEmbeddedAddress = "localhost:4222"
+
+const embeddedReadyTimeout = 10 * time.Second
+
+var (
+	errEmbeddedNotEnabled = errors.New("embedded NATS.io server is not enabled, use WithEmbeddedServer option first")
+	errEmbeddedNotReady   = errors.New("embedded NATS.io server is not ready for connections")
+)
+
+type embeddedOptions struct {
+	address   string
+	jetstream bool
+	storeDir  string
+}
+
+// startEmbeddedServer starts the in-process NATS.io server and waits for it to accept connections.
+func startEmbeddedServer(ctx context.Context, config *embeddedOptions) (*server.Server, error) {
+	host, portStr, err := net.SplitHostPort(config.address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedded NATS.io server address %q: %w", config.address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedded NATS.io server address %q: %w", config.address, err)
+	}
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      host,
+		Port:      port,
+		JetStream: config.jetstream,
+		StoreDir:  config.storeDir,
+		NoSigs:    true,
+		NoLog:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ns.Start()
+	if !ns.ReadyForConnections(embeddedReadyTimeout) {
+		ns.Shutdown()
+		return nil, errEmbeddedNotReady
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "embedded nats server started",
+		slog.String("url", ns.ClientURL()),
+		slog.Bool("jetstream", config.jetstream),
+		slog.String("store_dir", ns.StoreDir()))
+	return ns, nil
+}
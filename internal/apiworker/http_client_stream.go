@@ -0,0 +1,101 @@
+package apiworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsStatusHeader is the NATS.io status header, set to "503" on no responders.
+const natsStatusHeader = "Status"
+
+var errStreamSequence = errors.New("nats: reply stream message out of sequence")
+
+// streamBody reassembles the response body from the worker reply stream.
+// See streamResponseWriter for the worker side.
+type streamBody struct {
+	ctx    context.Context //nolint:containedctx // the body outlives RoundTrip
+	cancel context.CancelFunc
+	// idleTimeout bounds the wait for every reply message, zero means the ctx deadline only.
+	idleTimeout time.Duration
+	sub         *nats.Subscription
+	data        []byte
+	seq         int
+	ended       bool
+	trailer     http.Header
+	closeOnce   sync.Once
+}
+
+var _ io.ReadCloser = (*streamBody)(nil)
+
+// Read implements io.Reader.
+func (b *streamBody) Read(p []byte) (int, error) {
+	for len(b.data) == 0 {
+		if b.ended {
+			_ = b.Close()
+			return 0, io.EOF
+		}
+		msg, err := b.next()
+		if err != nil {
+			return 0, err
+		}
+		b.data = msg.Data
+		if b.ended && b.trailer != nil {
+			moveTrailers(b.trailer, http.Header(msg.Header))
+		}
+	}
+
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+// Close implements io.Closer.
+func (b *streamBody) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		err = b.sub.Unsubscribe()
+		b.cancel()
+	})
+	return err
+}
+
+// next waits for the next message of the reply stream.
+// Replies without StreamSeqHeader are treated as a single message stream,
+// the stream aborted by the worker (see StreamErrorHeader) ends with the Connect error.
+func (b *streamBody) next() (*nats.Msg, error) {
+	ctx, cancel := b.ctx, context.CancelFunc(func() {})
+	if b.idleTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, b.idleTimeout)
+	}
+	msg, err := b.sub.NextMsgWithContext(ctx)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Data) == 0 && msg.Header.Get(natsStatusHeader) == "503" {
+		return nil, nats.ErrNoResponders
+	}
+
+	if seq := msg.Header.Get(StreamSeqHeader); seq != "" {
+		if seq != strconv.Itoa(b.seq) {
+			return nil, fmt.Errorf("%w: expected %d, got %s", errStreamSequence, b.seq, seq)
+		}
+		b.seq++
+		b.ended, _ = strconv.ParseBool(msg.Header.Get(StreamEndHeader))
+		if code := msg.Header.Get(StreamErrorHeader); code != "" {
+			b.ended = true
+			return nil, streamErrorFromMsg(msg, code)
+		}
+	} else {
+		b.ended = true
+	}
+	return msg, nil
+}
@@ -0,0 +1,185 @@
+package apiworker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultClientTimeout is the timeout of the first reply, and of every next reply of the stream,
+// used when the request context has no deadline. The long-lived streams are not cut off while they send messages.
+const DefaultClientTimeout = 5 * time.Second
+
+type natsRoundTripper struct {
+	natsCon  *nats.Conn
+	timeout  time.Duration
+	subjects Subjects
+}
+
+// ClientOption configures the NATS.io HTTP client.
+type ClientOption func(*natsRoundTripper)
+
+// WithClientSubjects sets the subjects mapping of the worker the client invokes,
+// see WithSubjectPrefix and WithRouteSubject.
+func WithClientSubjects(subjects Subjects) ClientOption {
+	return func(t *natsRoundTripper) {
+		t.subjects = subjects
+	}
+}
+
+// WithClientTimeout sets the reply timeout used when the request context has no deadline, see DefaultClientTimeout.
+func WithClientTimeout(timeout time.Duration) ClientOption {
+	return func(t *natsRoundTripper) {
+		t.timeout = timeout
+	}
+}
+
+var _ http.RoundTripper = (*natsRoundTripper)(nil)
+
+// NewRoundTripper returns an http.RoundTripper which sends HTTP requests as NATS.io requests.
+// The request path is mapped to the subject served by the worker, see Subjects.
+// The URL scheme and host are ignored.
+func NewRoundTripper(natsCon *nats.Conn, options ...ClientOption) http.RoundTripper {
+	t := &natsRoundTripper{
+		natsCon: natsCon,
+		timeout: DefaultClientTimeout,
+	}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+// NewHTTPClient returns a connect.HTTPClient which invokes the worker over NATS.io.
+//
+// Example usage:
+//
+//	client := versionv1connect.NewVersionServiceClient(apiworker.NewHTTPClient(nc), "nats://")
+//	resp, err := client.GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+func NewHTTPClient(natsCon *nats.Conn, options ...ClientOption) connect.HTTPClient {
+	return &http.Client{Transport: NewRoundTripper(natsCon, options...)}
+}
+
+// RoundTrip implements http.RoundTripper.
+// The request is sent with the StreamHeader, so the response body is reassembled from the reply stream
+// and server-streaming procedures are supported. The bidi streaming procedures are supported half-duplex:
+// the request is sent once the client closes the request stream.
+func (t *natsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var data []byte
+	if req.Body != nil {
+		var err error
+		data, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	var idleTimeout time.Duration
+	if _, ok := ctx.Deadline(); !ok {
+		idleTimeout = t.timeout
+	}
+
+	sub, err := t.natsCon.SubscribeSync(t.natsCon.NewRespInbox())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	body := &streamBody{ctx: ctx, cancel: cancel, idleTimeout: idleTimeout, sub: sub}
+
+	msg := &nats.Msg{
+		Subject: t.subjects.Subject(req.URL.Path),
+		Reply:   sub.Subject,
+		Data:    data,
+		Header:  nats.Header(req.Header.Clone()),
+	}
+	msg.Header.Set(StreamHeader, "true")
+	if err = t.natsCon.PublishMsg(msg); err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+
+	reply, err := body.next()
+	if err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+
+	resp, err := newResponseFromMessage(req, reply)
+	if err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+	body.data = reply.Data
+	body.trailer = resp.Trailer
+	if !body.ended {
+		resp.ContentLength = -1
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+// newResponseFromMessage converts the worker reply message to an HTTP response.
+func newResponseFromMessage(req *http.Request, reply *nats.Msg) (*http.Response, error) {
+	// nats-server rewrites the W3C "traceparent" key in lower case
+	header := make(http.Header, len(reply.Header))
+	for k, v := range reply.Header {
+		k = http.CanonicalHeaderKey(k)
+		header[k] = append(header[k], v...)
+	}
+
+	statusCode := http.StatusOK
+	if status := header.Get("X-Status-Code"); status != "" {
+		var err error
+		statusCode, err = strconv.Atoi(status)
+		if err != nil {
+			return nil, err
+		}
+		header.Del("X-Status-Code")
+	}
+	header.Del(StreamSeqHeader)
+	header.Del(StreamEndHeader)
+
+	var trailer http.Header
+	if isGRPCContentType(header.Get("Content-Type")) {
+		trailer = make(http.Header)
+		moveTrailers(trailer, header)
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		ProtoMinor:    0,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(reply.Data)),
+		ContentLength: int64(len(reply.Data)),
+		Trailer:       trailer,
+		Request:       req,
+	}, nil
+}
+
+// moveTrailers moves headers prefixed with TrailerHeaderPrefix to trailer.
+func moveTrailers(trailer, header http.Header) {
+	for k, v := range header {
+		if name, ok := strings.CutPrefix(k, TrailerHeaderPrefix); ok {
+			trailer[http.CanonicalHeaderKey(name)] = v
+			delete(header, k)
+		}
+	}
+}
+
+// isGRPCContentType reports whether the content type is gRPC, where trailers are sent as HTTP trailers.
+// gRPC-Web sends trailers in the body.
+func isGRPCContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/grpc") && !strings.HasPrefix(contentType, "application/grpc-web")
+}
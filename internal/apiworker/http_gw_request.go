@@ -4,32 +4,29 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 
 	"github.com/nats-io/nats.go"
 )
 
+// DefaultContentType is the request content type used when the message has no Content-Type header.
+const DefaultContentType = "application/json"
+
+// NewRequestFromMessage converts the NATS.io message to the HTTP request for the handler path.
+// Message headers are copied as is, the Content-Type header defaults to DefaultContentType.
+// The request is HTTP/2: the message carries the whole request stream, so the bidi streaming
+// procedures, e.g. the gRPC server reflection, are served half-duplex.
 func NewRequestFromMessage(msg *nats.Msg, subscribePath, handlerPath string) (*http.Request, error) {
 	url := subjectToURL(msg.Subject, subscribePath, handlerPath)
 
 	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(msg.Data))
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
 
-	req.Header.Set("Content-Type", "application/json")
 	for k, v := range msg.Header {
-		for _, vv := range v {
-			req.Header.Add(k, vv)
-		}
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", DefaultContentType)
 	}
 
 	return req, nil
 }
-
-func urlToSuscribeSubject(url string) string {
-	subj := strings.Trim(url, "/")
-	subj = strings.ReplaceAll(subj, "/", ".")
-	return subj + ".>"
-}
-
-func subjectToURL(subj, subscribePath, handlerPath string) string {
-	return handlerPath + subj[len(subscribePath)-1:]
-}
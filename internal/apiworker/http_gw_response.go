@@ -5,12 +5,22 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
 )
 
+// TrailerHeaderPrefix is the reply header prefix of HTTP trailers, e.g. gRPC status trailers.
+// It follows the Connect unary protocol convention, as NATS.io messages have no trailers.
+const TrailerHeaderPrefix = "Trailer-"
+
 type stdResponseWriter struct {
 	io.Writer
 	header     http.Header
 	statusCode int
+	// defaultContentType is the reply Content-Type if the handler does not set one.
+	defaultContentType string
 }
 
 var (
@@ -57,3 +67,30 @@ func (w *stdResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 func (w *stdResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
+
+// status returns the response status code, which is 200 OK unless set by the handler.
+func (w *stdResponseWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+// replyHeader returns the response headers as NATS.io reply headers, including the X-Status-Code header.
+// HTTP trailers are sent as headers prefixed with TrailerHeaderPrefix.
+func (w *stdResponseWriter) replyHeader() nats.Header {
+	header := make(nats.Header, len(w.header)+2)
+	for k, v := range w.header {
+		if trailer, ok := strings.CutPrefix(k, http.TrailerPrefix); ok {
+			k = TrailerHeaderPrefix + http.CanonicalHeaderKey(trailer)
+		} else {
+			k = http.CanonicalHeaderKey(k)
+		}
+		header[k] = append(header[k], v...)
+	}
+	if header.Get("Content-Type") == "" && w.defaultContentType != "" {
+		header.Set("Content-Type", w.defaultContentType)
+	}
+	header.Set("X-Status-Code", strconv.Itoa(w.status()))
+	return header
+}
@@ -0,0 +1,124 @@
+package apiworker
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// StreamHeader is the request header the caller sets to accept the response as a stream of messages.
+	StreamHeader = "X-Stream"
+	// StreamSeqHeader is the sequence number of the response stream message, starting from 0.
+	StreamSeqHeader = "X-Stream-Seq"
+	// StreamEndHeader marks the last response stream message, which carries the trailers.
+	StreamEndHeader = "X-Stream-End"
+	// StreamErrorHeader is the Connect error code of the stream aborted by the worker, e.g. on the deadline.
+	// The last message carries the shared.v1.Error JSON instead of the response data.
+	StreamErrorHeader = "X-Stream-Error"
+)
+
+// streamResponseWriter publishes every flushed chunk of the response as a separate reply message.
+// The first message carries the response headers, the last message (see endMsg) carries the trailers.
+// Once the stream is aborted (see abort), the handler chunks are not published anymore.
+type streamResponseWriter struct {
+	*stdResponseWriter
+	buffer  *bytes.Buffer
+	subject string
+	publish func(*nats.Msg) error
+	err     error
+
+	// m guards the stream sequence against the abort while the handler publishes.
+	m          sync.Mutex
+	seq        int
+	headerSent bool
+	aborted    bool
+}
+
+var _ http.Flusher = (*streamResponseWriter)(nil)
+
+func newStreamResponseWriter(
+	w *stdResponseWriter,
+	buffer *bytes.Buffer,
+	subject string,
+	publish func(*nats.Msg) error,
+) *streamResponseWriter {
+	return &streamResponseWriter{
+		stdResponseWriter: w,
+		buffer:            buffer,
+		subject:           subject,
+		publish:           publish,
+	}
+}
+
+// isStreamRequest reports whether the caller accepts the response as a stream of messages.
+func isStreamRequest(req *http.Request) bool {
+	accept, _ := strconv.ParseBool(req.Header.Get(StreamHeader))
+	return accept
+}
+
+// Flush implements http.Flusher.
+func (w *streamResponseWriter) Flush() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.aborted || w.err != nil || (w.headerSent && w.buffer.Len() == 0) {
+		return
+	}
+	msg := w.nextMsg()
+	msg.Subject = w.subject
+	// On error the caller notices the sequence gap, stop streaming.
+	w.err = w.publish(msg)
+}
+
+// endMsg returns the last message of the stream with the remaining data and trailers.
+func (w *streamResponseWriter) endMsg() *nats.Msg {
+	w.m.Lock()
+	defer w.m.Unlock()
+	headerSent := w.headerSent
+	msg := w.nextMsg()
+	if headerSent {
+		for k, v := range w.replyHeader() {
+			if strings.HasPrefix(k, TrailerHeaderPrefix) {
+				msg.Header[k] = v
+			}
+		}
+	}
+	msg.Header.Set(StreamEndHeader, "true")
+	return msg
+}
+
+// abort makes the error reply the last message of the stream, the handler chunks are not published afterwards.
+// The reply is the whole response if no message was published yet, otherwise it is marked with StreamErrorHeader.
+func (w *streamResponseWriter) abort(reply *nats.Msg, code connect.Code) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.aborted = true
+	if w.headerSent {
+		reply.Header.Set(StreamErrorHeader, code.String())
+	}
+	reply.Header.Set(StreamSeqHeader, strconv.Itoa(w.seq))
+	reply.Header.Set(StreamEndHeader, "true")
+	w.seq++
+}
+
+func (w *streamResponseWriter) nextMsg() *nats.Msg {
+	header := nats.Header{}
+	if !w.headerSent {
+		header = w.replyHeader()
+		w.headerSent = true
+	}
+	header.Set(StreamSeqHeader, strconv.Itoa(w.seq))
+	w.seq++
+
+	data := bytes.Clone(w.buffer.Bytes())
+	w.buffer.Reset()
+	return &nats.Msg{
+		Header: header,
+		Data:   data,
+	}
+}
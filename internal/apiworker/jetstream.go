@@ -0,0 +1,186 @@
+package apiworker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// ReplyToHeader is the message header with the subject the JetStream worker publishes replies to.
+	// JetStream uses the message reply subject for acks, so the reply subject has to be passed as a header.
+	ReplyToHeader = "X-Reply-To"
+
+	defaultJetStreamMaxDeliver = 5
+	defaultJetStreamNakDelay   = time.Second
+	defaultJetStreamAckWait    = 30 * time.Second
+	maxJetStreamNakDelay       = time.Minute
+
+	// statusClientClosedRequest is the HTTP status of the canceled request, see apierrors.
+	statusClientClosedRequest = 499
+)
+
+var (
+	errJetStreamNotEnabled = errors.New("jetstream is not enabled, use WithJetStream option first")
+	errInvalidAckWait      = errors.New("jetstream ack wait must be positive")
+)
+
+// subscribeJetStream binds route subjects to the stream and starts a durable pull consumer per route.
+func (w *worker) subscribeJetStream(ctx context.Context) error {
+	config := w.config.jetstream
+	js, err := jetstream.New(w.natsCon)
+	if err != nil {
+		return err
+	}
+
+	subjects := make([]string, 0, len(w.routes))
+	for _, route := range w.routes {
+		subjects = append(subjects, w.config.subjects.subscribeSubject(route.Path()))
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      config.stream,
+		Subjects:  subjects,
+		Retention: jetstream.WorkQueuePolicy,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, route := range w.routes {
+		subscribePath := w.config.subjects.subscribeSubject(route.Path())
+		consumer, err := js.CreateOrUpdateConsumer(ctx, config.stream, jetstream.ConsumerConfig{
+			Durable:       consumerName(config.durable, route.Path()),
+			FilterSubject: subscribePath,
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			AckWait:       config.ackWait,
+			MaxDeliver:    config.maxDeliver,
+		})
+		if err != nil {
+			return err
+		}
+
+		var consumeOpts []jetstream.PullConsumeOpt
+		if w.config.pendingMsgs > 0 {
+			consumeOpts = append(consumeOpts, jetstream.PullMaxMessages(w.config.pendingMsgs))
+		}
+		handler := concurrentMsgHandler(w, subscribePath, w.jetStreamMsgHandler(ctx, subscribePath, route.Path()))
+		consumeCtx, err := consumer.Consume(handler, consumeOpts...)
+		if err != nil {
+			return err
+		}
+		w.consumers = append(w.consumers, consumeCtx)
+		slog.LogAttrs(ctx, slog.LevelInfo, "jetstream consume",
+			slog.String("stream", config.stream),
+			slog.String("consumer", consumer.CachedInfo().Name),
+			slog.String("subject", subscribePath),
+			slog.Int("max_inflight", w.concurrency()))
+	}
+
+	return nil
+}
+
+func (w *worker) jetStreamMsgHandler(ctx context.Context, subscribePath, handlerPath string) jetstream.MessageHandler {
+	config := w.config.jetstream
+	return func(msg jetstream.Msg) {
+		replyTo := msg.Headers().Get(ReplyToHeader)
+		stopProgress := inProgress(ctx, msg, config.ackWait)
+		w.serveMsg(ctx, &nats.Msg{
+			Subject: msg.Subject(),
+			Reply:   replyTo,
+			Data:    msg.Data(),
+			Header:  msg.Headers(),
+		}, subscribePath, handlerPath, func(reply *nats.Msg, statusCode int) {
+			stopProgress()
+
+			var numDelivered uint64 = 1
+			if meta, err := msg.Metadata(); err == nil {
+				numDelivered = meta.NumDelivered
+			}
+
+			// the canceled request is not handled, it is redelivered like the failed one
+			failed := statusCode >= http.StatusInternalServerError || statusCode == statusClientClosedRequest
+			var err error
+			switch {
+			case failed && numDelivered < uint64(config.maxDeliver):
+				delay := nakDelay(config.nakDelay, numDelivered)
+				slog.LogAttrs(ctx, slog.LevelWarn, "jetstream message nak",
+					slog.String("subject", msg.Subject()),
+					slog.Int("status_code", statusCode),
+					slog.Uint64("num_delivered", numDelivered),
+					slog.Duration("delay", delay))
+				if err = msg.NakWithDelay(delay); err != nil {
+					slog.LogAttrs(ctx, slog.LevelError, "jetstream nak error",
+						slog.String("subject", msg.Subject()),
+						slog.String("error", err.Error()))
+				}
+				// Reply only once the message is processed, redelivery may still succeed.
+				return
+			case failed:
+				err = msg.TermWithReason("max deliver reached")
+			default:
+				err = msg.Ack()
+			}
+			if err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "jetstream ack error",
+					slog.String("subject", msg.Subject()),
+					slog.String("error", err.Error()))
+			}
+
+			if replyTo == "" {
+				return
+			}
+			reply.Subject = replyTo
+			reply.Reply = ""
+			if err = w.natsCon.PublishMsg(reply); err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "NATS.io to gPRC response error",
+					slog.String("subject", msg.Subject()),
+					slog.String("error", err.Error()))
+			}
+		})
+	}
+}
+
+// inProgress resets the message ack wait while the message is handled, so the long requests are not redelivered
+// before they complete, see WithAckWait. The returned func stops the progress heartbeats.
+func inProgress(ctx context.Context, msg jetstream.Msg, ackWait time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ackWait / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := msg.InProgress(); err != nil {
+					slog.LogAttrs(ctx, slog.LevelWarn, "jetstream in progress error",
+						slog.String("subject", msg.Subject()),
+						slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// nakDelay returns the exponential backoff delay for the given delivery attempt.
+func nakDelay(base time.Duration, numDelivered uint64) time.Duration {
+	delay := base
+	for i := uint64(1); i < numDelivered && delay < maxJetStreamNakDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxJetStreamNakDelay)
+}
+
+// consumerName builds a durable consumer name for the route, e.g. "durable_version_v1_VersionService".
+func consumerName(durable, path string) string {
+	name := strings.Trim(path, "/")
+	name = strings.NewReplacer("/", "_", ".", "_", "*", "_", ">", "_", " ", "_").Replace(name)
+	return durable + "_" + name
+}
@@ -0,0 +1,128 @@
+package apiworker
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+)
+
+// microFullVersionKey is the micro service metadata key with the unmodified service version.
+const microFullVersionKey = "full_version"
+
+var errMicroPendingLimits = errors.New("pending limits are not supported by the micro service endpoints")
+
+var (
+	microNameInvalidChars    = regexp.MustCompile(`[^A-Za-z0-9\-_]`)
+	microVersionInvalidChars = regexp.MustCompile(`[^0-9A-Za-z\-]+`)
+	// semVerRegexp is the semver validation regexp used by the micro package.
+	semVerRegexp = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+)
+
+type microOptions struct {
+	name    string
+	version string
+}
+
+// subscribeMicro registers every route as an endpoint of the NATS.io micro service,
+// which makes the worker discoverable with $SRV.PING, $SRV.INFO and $SRV.STATS requests.
+func (w *worker) subscribeMicro(ctx context.Context) error {
+	config := w.config.micro
+	svc, err := micro.AddService(w.natsCon, micro.Config{
+		Name:       microServiceName(config.name),
+		Version:    microServiceVersion(config.version),
+		QueueGroup: w.config.queueGroup,
+		Metadata:   map[string]string{microFullVersionKey: config.version},
+	})
+	if err != nil {
+		return err
+	}
+	w.service = svc
+
+	for _, route := range w.routes {
+		subscribePath := w.config.subjects.subscribeSubject(route.Path())
+		opts := []micro.EndpointOpt{micro.WithEndpointSubject(subscribePath)}
+		if w.config.queueGroup == "" {
+			opts = append(opts, micro.WithEndpointQueueGroupDisabled())
+		}
+		name := microServiceName(strings.ReplaceAll(strings.Trim(route.Path(), "/"), ".", "_"))
+		w.health.dispatch(subscribePath)
+		if err = svc.AddEndpoint(name, w.microHandler(ctx, subscribePath, route.Path()), opts...); err != nil {
+			return err
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "nats micro endpoint",
+			slog.String("service", svc.Info().Name),
+			slog.String("endpoint", name),
+			slog.String("subject", subscribePath),
+			slog.String("queue", w.config.queueGroup),
+			slog.Int("max_inflight", 1))
+	}
+
+	return nil
+}
+
+// microHandler handles the endpoint requests one at a time and replies through the micro request,
+// so $SRV.STATS reports the processing time and the errors (5xx status) of the endpoint requests.
+// The next requests wait in the endpoint subscription, which does not report them as pending,
+// so the route is stuck once a request is handled for longer than the dispatch stuck timeout, see checkDispatch.
+func (w *worker) microHandler(ctx context.Context, subscribePath, handlerPath string) micro.Handler {
+	return micro.HandlerFunc(func(req micro.Request) {
+		w.background.Add(1)
+		defer w.background.Add(-1)
+		defer w.health.serve(subscribePath)()
+
+		msg := &nats.Msg{
+			Subject: req.Subject(),
+			Reply:   req.Reply(),
+			Data:    req.Data(),
+			Header:  nats.Header(req.Headers()),
+		}
+		w.serveMsg(ctx, msg, subscribePath, handlerPath, func(reply *nats.Msg, statusCode int) {
+			if msg.Reply == "" {
+				return
+			}
+			headers := micro.WithHeaders(micro.Headers(reply.Header))
+			var err error
+			if statusCode >= http.StatusInternalServerError {
+				// counted as the endpoint error by $SRV.STATS
+				err = req.Error(strconv.Itoa(statusCode), cmp.Or(http.StatusText(statusCode), "error"), reply.Data, headers)
+			} else {
+				err = req.Respond(reply.Data, headers)
+			}
+			if err != nil {
+				slog.LogAttrs(ctx, slog.LevelError,
+					"NATS.io to gPRC response error",
+					slog.String("subject", msg.Subject),
+					slog.String("error", err.Error()),
+				)
+			}
+		})
+	})
+}
+
+// microServiceName replaces characters not allowed in micro service and endpoint names.
+func microServiceName(name string) string {
+	return microNameInvalidChars.ReplaceAllString(name, "_")
+}
+
+// microServiceVersion returns the version if it is a valid semver (optionally prefixed with "v"),
+// otherwise the version is added as build metadata to "0.0.0".
+func microServiceVersion(version string) string {
+	if v := strings.TrimPrefix(version, "v"); semVerRegexp.MatchString(v) {
+		return v
+	}
+	build := strings.Trim(microVersionInvalidChars.ReplaceAllString(version, "."), ".")
+	if build == "" {
+		return "0.0.0"
+	}
+	return "0.0.0+" + build
+}
@@ -0,0 +1,108 @@
+package apiworker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errInvalidContextName = errors.New("invalid NATS.io context name")
+
+// natsContext is the subset of the `nats context` settings used by the worker.
+// See https://docs.nats.io/using-nats/nats-tools/nats_cli#nats-contexts.
+type natsContext struct {
+	URL         string `json:"url"`
+	Token       string `json:"token"`
+	User        string `json:"user"`
+	Password    string `json:"password"`
+	Creds       string `json:"creds"`
+	NKey        string `json:"nkey"`
+	Cert        string `json:"cert"`
+	Key         string `json:"key"`
+	CA          string `json:"ca"`
+	InboxPrefix string `json:"inbox_prefix"`
+}
+
+// loadNatsContext loads the named context from the `nats` CLI configuration directory,
+// `$XDG_CONFIG_HOME/nats/context/<name>.json` or `~/.config/nats/context/<name>.json`.
+// The name may also be a path to a context JSON file.
+func loadNatsContext(name string) (*natsContext, error) {
+	path, err := natsContextPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NATS.io context %q: %w", name, err)
+	}
+	nctx := &natsContext{}
+	if err = json.Unmarshal(data, nctx); err != nil {
+		return nil, fmt.Errorf("failed to parse NATS.io context %q: %w", name, err)
+	}
+	for _, path := range []*string{&nctx.Creds, &nctx.NKey, &nctx.Cert, &nctx.Key, &nctx.CA} {
+		if *path, err = expandHomePath(*path); err != nil {
+			return nil, err
+		}
+	}
+	return nctx, nil
+}
+
+func natsContextPath(name string) (string, error) {
+	if strings.HasSuffix(name, ".json") {
+		return expandHomePath(name)
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%w: %q", errInvalidContextName, name)
+	}
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "nats", "context", name+".json"), nil
+}
+
+func expandHomePath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// applyContext fills the connection options not set explicitly from the configured NATS.io context.
+func (o *natsOptions) applyContext() error {
+	if o.context == "" {
+		return nil
+	}
+	nctx, err := loadNatsContext(o.context)
+	if err != nil {
+		return err
+	}
+	for _, field := range []struct{ option, value *string }{
+		{&o.url, &nctx.URL},
+		{&o.token, &nctx.Token},
+		{&o.user, &nctx.User},
+		{&o.password, &nctx.Password},
+		{&o.creds, &nctx.Creds},
+		{&o.nkey, &nctx.NKey},
+		{&o.tlscert, &nctx.Cert},
+		{&o.tlskey, &nctx.Key},
+		{&o.tlsca, &nctx.CA},
+		{&o.inboxPrefix, &nctx.InboxPrefix},
+	} {
+		if *field.option == "" {
+			*field.option = *field.value
+		}
+	}
+	return nil
+}
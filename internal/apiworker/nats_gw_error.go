@@ -1,16 +1,42 @@
 package apiworker
 
 import (
+	"context"
+	"errors"
+	"strconv"
+
+	"connectrpc.com/connect"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/leonardinius/go-service-template/internal/apierrors"
 	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
 )
 
-func errToSharedError(err error) *sharedv1.Error {
-	if err == nil {
-		return nil
-	}
+// newErrorReplyMsg returns the gateway error reply message with the shared.v1.Error JSON body, see apierrors.Error,
+// and the reply HTTP status code.
+func newErrorReplyMsg(ctx context.Context, msg *nats.Msg, err error) (*nats.Msg, int) {
+	statusCode := apierrors.From(err).HTTPStatus()
+	header := nats.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Status-Code", strconv.Itoa(statusCode))
+	return &nats.Msg{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    apierrors.MarshalJSON(ctx, err),
+		Header:  header,
+	}, statusCode
+}
 
-	return &sharedv1.Error{
-		Code:    500,
-		Message: err.Error(),
+// streamErrorFromMsg returns the Connect error of the stream aborted by the worker, see streamResponseWriter.abort.
+func streamErrorFromMsg(msg *nats.Msg, code string) error {
+	var connectCode connect.Code
+	if err := connectCode.UnmarshalText([]byte(code)); err != nil {
+		connectCode = connect.CodeUnknown
+	}
+	shared := &sharedv1.Error{}
+	if err := protojson.Unmarshal(msg.Data, shared); err != nil || shared.GetMessage() == "" {
+		return connect.NewError(connectCode, errors.New(connectCode.String()))
 	}
+	return connect.NewError(connectCode, errors.New(shared.GetMessage()))
 }
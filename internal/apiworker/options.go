@@ -3,21 +3,53 @@ package apiworker
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	natsio "github.com/nats-io/nats.go"
 )
 
 type natsOptions struct {
-	metricsAddress string
-	url            string
-	user           string
-	password       string
-	creds          string
-	nkey           string
-	tlscert        string
-	tlskey         string
-	tlsca          string
-	context        string
+	metricsAddress       string
+	url                  string
+	token                string
+	user                 string
+	password             string
+	creds                string
+	nkey                 string
+	tlscert              string
+	tlskey               string
+	tlsca                string
+	context              string
+	inboxPrefix          string
+	queueGroup           string
+	embedded             *embeddedOptions
+	admin                string
+	subjects             Subjects
+	jetstream            *jetStreamOptions
+	micro                *microOptions
+	defaultTimeout       time.Duration
+	maxTimeout           time.Duration
+	maxInFlight          int
+	pendingMsgs          int
+	pendingBytes         int
+	maxReconnects        int
+	reconnectWait        time.Duration
+	reconnectJitter      time.Duration
+	reconnectJitterTLS   time.Duration
+	pingInterval         time.Duration
+	reconnectBufSize     int
+	connectTimeout       time.Duration
+	retryOnFailedConnect bool
+	drainTimeout         time.Duration
+	dispatchStuckTimeout time.Duration
+}
+
+type jetStreamOptions struct {
+	stream     string
+	durable    string
+	maxDeliver int
+	nakDelay   time.Duration
+	ackWait    time.Duration
 }
 
 type Option interface {
@@ -44,6 +76,13 @@ func WithURL(url string) Option {
 	})
 }
 
+func WithToken(token string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.token = token
+		return nil
+	})
+}
+
 func WithUser(user string) Option {
 	return funcOption(func(o *natsOptions) error {
 		o.user = user
@@ -93,6 +132,9 @@ func WithTLSCA(tlsca string) Option {
 	})
 }
 
+// WithContextName loads the connection settings from the `nats` CLI context:
+// server URLs, token, user and password, credentials, nkey, TLS files and inbox prefix.
+// The name may also be a path to the context JSON file. Options set explicitly take precedence over the context.
 func WithContextName(contextName string) Option {
 	return funcOption(func(o *natsOptions) error {
 		o.context = contextName
@@ -100,14 +142,285 @@ func WithContextName(contextName string) Option {
 	})
 }
 
+func WithInboxPrefix(inboxPrefix string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.inboxPrefix = inboxPrefix
+		return nil
+	})
+}
+
+// WithQueueGroup sets the NATS.io queue group used for route subscriptions.
+// Workers sharing the same queue group load-balance requests, so each request is handled exactly once.
+// Empty queue group means every worker receives every request.
+func WithQueueGroup(queueGroup string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.queueGroup = queueGroup
+		return nil
+	})
+}
+
+// WithSubjectPrefix sets the subject namespace of all routes, e.g. "prod.svcname.",
+// so environments or tenants sharing a NATS.io account do not collide.
+func WithSubjectPrefix(prefix string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.subjects.Prefix = prefix
+		return nil
+	})
+}
+
+// WithRouteSubject overrides the subject of the route path, see Subjects.Routes.
+func WithRouteSubject(routePath, subject string) Option {
+	return funcOption(func(o *natsOptions) error {
+		if o.subjects.Routes == nil {
+			o.subjects.Routes = make(map[string]string)
+		}
+		o.subjects.Routes[routePath] = subject
+		return nil
+	})
+}
+
+// WithJetStream switches the worker to JetStream mode.
+// Route subjects are bound to the given stream, and each route is served by a durable pull consumer,
+// so requests published while no worker is running are not lost.
+func WithJetStream(stream, durable string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.jetstream = &jetStreamOptions{
+			stream:     stream,
+			durable:    durable,
+			maxDeliver: defaultJetStreamMaxDeliver,
+			nakDelay:   defaultJetStreamNakDelay,
+			ackWait:    defaultJetStreamAckWait,
+		}
+		return nil
+	})
+}
+
+// WithMaxDeliver sets the maximum number of delivery attempts for a JetStream message.
+// Must be used after WithJetStream.
+func WithMaxDeliver(maxDeliver int) Option {
+	return funcOption(func(o *natsOptions) error {
+		if o.jetstream == nil {
+			return errJetStreamNotEnabled
+		}
+		o.jetstream.maxDeliver = maxDeliver
+		return nil
+	})
+}
+
+// WithNakDelay sets the base redelivery delay for JetStream messages failed with 5xx status or canceled (499).
+// The delay doubles with every delivery attempt. Must be used after WithJetStream.
+func WithNakDelay(delay time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		if o.jetstream == nil {
+			return errJetStreamNotEnabled
+		}
+		o.jetstream.nakDelay = delay
+		return nil
+	})
+}
+
+// WithAckWait sets the time the JetStream server waits for the message ack before the redelivery.
+// The worker extends it while the request is handled, so the requests longer than the ack wait are not redelivered.
+// Must be used after WithJetStream.
+func WithAckWait(ackWait time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		if o.jetstream == nil {
+			return errJetStreamNotEnabled
+		}
+		if ackWait <= 0 {
+			return errInvalidAckWait
+		}
+		o.jetstream.ackWait = ackWait
+		return nil
+	})
+}
+
+// WithEmbeddedServer starts the in-process NATS.io server listening on the address, e.g. DefaultEmbeddedAddress.
+// The worker connects to it instead of the configured server URLs and shuts it down on Shutdown.
+func WithEmbeddedServer(address string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.embedded = &embeddedOptions{address: address}
+		return nil
+	})
+}
+
+// WithEmbeddedJetStream enables JetStream on the embedded server, storing data in storeDir.
+// Empty storeDir means the NATS.io server default, a directory in the system temporary directory.
+func WithEmbeddedJetStream(storeDir string) Option {
+	return funcOption(func(o *natsOptions) error {
+		if o.embedded == nil {
+			return errEmbeddedNotEnabled
+		}
+		o.embedded.jetstream = true
+		o.embedded.storeDir = storeDir
+		return nil
+	})
+}
+
+// WithAdmin subscribes every worker instance, without a queue group, to the service admin subjects,
+// so a scatter-gather request is answered by every instance, see AdminSubject.
+func WithAdmin(service string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.admin = service
+		return nil
+	})
+}
+
+// WithMicroService registers routes as endpoints of the NATS.io micro service with the given name and version.
+// Invalid characters are replaced, so the service name and version are valid for the micro package.
+// The micro service owns the endpoint subscriptions, so it is not supported with WithPendingLimits.
+// The endpoints handle one request at a time, so $SRV.STATS reports the request processing time and errors,
+// WithMaxInFlight does not apply.
+// It has no effect in JetStream mode.
+func WithMicroService(name, version string) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.micro = &microOptions{
+			name:    name,
+			version: version,
+		}
+		return nil
+	})
+}
+
+// WithDefaultTimeout sets the request timeout used when the message has no timeout header.
+// Zero means no timeout.
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.defaultTimeout = timeout
+		return nil
+	})
+}
+
+// WithMaxTimeout caps the request timeout requested by the message timeout header.
+// Zero means no limit.
+func WithMaxTimeout(timeout time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.maxTimeout = timeout
+		return nil
+	})
+}
+
+// WithMaxInFlight sets the maximum number of requests handled concurrently per route.
+// Values less than 1 mean 1. It does not apply to the micro service endpoints, see WithMicroService.
+func WithMaxInFlight(maxInFlight int) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.maxInFlight = maxInFlight
+		return nil
+	})
+}
+
+// WithPendingLimits sets the limits of messages and bytes buffered per subscription before the messages are dropped.
+// In JetStream mode msgs limits the number of messages buffered by the route consumer.
+// Zero means the NATS.io client default, negative means unlimited. Not supported with WithMicroService.
+func WithPendingLimits(msgs, bytes int) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.pendingMsgs = msgs
+		o.pendingBytes = bytes
+		return nil
+	})
+}
+
+// WithMaxReconnects sets the number of reconnect attempts before the connection is closed.
+// Negative value means the worker never gives up reconnecting.
+func WithMaxReconnects(maxReconnects int) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.maxReconnects = maxReconnects
+		return nil
+	})
+}
+
+// WithReconnectWait sets the wait time between reconnect attempts to the same server.
+func WithReconnectWait(wait time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.reconnectWait = wait
+		return nil
+	})
+}
+
+// WithReconnectJitter sets the upper bound of the random delay added to the reconnect wait,
+// for plain and TLS connections.
+func WithReconnectJitter(jitter, jitterTLS time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.reconnectJitter = jitter
+		o.reconnectJitterTLS = jitterTLS
+		return nil
+	})
+}
+
+// WithPingInterval sets the interval of the client to server pings, used to detect stale connections.
+func WithPingInterval(interval time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.pingInterval = interval
+		return nil
+	})
+}
+
+// WithReconnectBufferSize sets the size of the buffer holding published messages while reconnecting.
+// Negative value disables the buffer, publishing fails while disconnected.
+func WithReconnectBufferSize(size int) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.reconnectBufSize = size
+		return nil
+	})
+}
+
+// WithConnectTimeout sets the timeout of the dial to a server.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.connectTimeout = timeout
+		return nil
+	})
+}
+
+// WithRetryOnFailedConnect keeps reconnecting if no server is available at startup, see WithMaxReconnects.
+// Core NATS.io and micro subscriptions are registered once connected, JetStream mode waits for the connection.
+func WithRetryOnFailedConnect(retry bool) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.retryOnFailedConnect = retry
+		return nil
+	})
+}
+
+// WithDrainTimeout sets the shutdown deadline: the time to wait for the requests in flight to complete,
+// and for the connection to drain. Requests still in flight after the deadline are abandoned.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.drainTimeout = timeout
+		return nil
+	})
+}
+
+// WithDispatchStuckTimeout sets the time after which a route with pending messages and no dispatched requests
+// is stuck, which fails the liveness probe, see DefaultDispatchStuckTimeout.
+func WithDispatchStuckTimeout(timeout time.Duration) Option {
+	return funcOption(func(o *natsOptions) error {
+		o.dispatchStuckTimeout = timeout
+		return nil
+	})
+}
+
 func newNatsOptions(opts ...Option) (*natsOptions, error) {
-	options := &natsOptions{}
+	options := &natsOptions{
+		maxInFlight:          1,
+		maxReconnects:        natsio.DefaultMaxReconnect,
+		reconnectWait:        natsio.DefaultReconnectWait,
+		reconnectJitter:      natsio.DefaultReconnectJitter,
+		reconnectJitterTLS:   natsio.DefaultReconnectJitterTLS,
+		pingInterval:         natsio.DefaultPingInterval,
+		reconnectBufSize:     natsio.DefaultReconnectBufSize,
+		connectTimeout:       natsio.DefaultTimeout,
+		drainTimeout:         natsio.DefaultDrainTimeout,
+		dispatchStuckTimeout: DefaultDispatchStuckTimeout,
+	}
 	for _, opt := range opts {
 		err := opt.apply(options)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if options.micro != nil && options.jetstream == nil && (options.pendingMsgs != 0 || options.pendingBytes != 0) {
+		return nil, errMicroPendingLimits
+	}
 	return options, nil
 }
 
@@ -116,7 +429,22 @@ func buildNatsIOOptions(ctx context.Context, opts ...Option) (*natsOptions, []na
 	if err != nil {
 		return nil, nil, err
 	}
-	var natsioOptions []natsio.Option = nil
+	if err = config.applyContext(); err != nil {
+		return nil, nil, err
+	}
+	natsioOptions := []natsio.Option{
+		natsio.MaxReconnects(config.maxReconnects),
+		natsio.ReconnectWait(config.reconnectWait),
+		natsio.ReconnectJitter(config.reconnectJitter, config.reconnectJitterTLS),
+		natsio.PingInterval(config.pingInterval),
+		natsio.ReconnectBufSize(config.reconnectBufSize),
+		natsio.Timeout(config.connectTimeout),
+		natsio.RetryOnFailedConnect(config.retryOnFailedConnect),
+		natsio.DrainTimeout(config.drainTimeout),
+	}
+	if config.token != "" {
+		natsioOptions = append(natsioOptions, natsio.Token(config.token))
+	}
 	if config.user != "" {
 		natsioOptions = append(natsioOptions, natsio.UserInfo(config.user, config.password))
 	}
@@ -136,6 +464,9 @@ func buildNatsIOOptions(ctx context.Context, opts ...Option) (*natsOptions, []na
 	if config.tlsca != "" {
 		natsioOptions = append(natsioOptions, natsio.RootCAs(config.tlsca))
 	}
+	if config.inboxPrefix != "" {
+		natsioOptions = append(natsioOptions, natsio.CustomInboxPrefix(config.inboxPrefix))
+	}
 
 	natsConCb := func(name string) natsio.ConnHandler {
 		return func(nc *natsio.Conn) {
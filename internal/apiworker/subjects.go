@@ -0,0 +1,62 @@
+package apiworker
+
+import (
+	"strings"
+)
+
+// Subjects maps HTTP handler paths to NATS.io subjects and back.
+// The zero value maps paths mechanically, e.g.
+// "/version.v1.VersionService/GetVersion" becomes "version.v1.VersionService.GetVersion".
+type Subjects struct {
+	// Prefix is the subject namespace prepended to every subject, e.g. "prod.svcname.".
+	Prefix string
+	// Routes overrides the subjects of route paths, e.g. "/version.v1.VersionService/" to "version",
+	// so "/version.v1.VersionService/GetVersion" becomes "version.GetVersion". The Prefix still applies.
+	// Leading and trailing slashes of the route paths are optional.
+	Routes map[string]string
+}
+
+// Subject returns the request subject of the HTTP path.
+func (s Subjects) Subject(path string) string {
+	routePath, routeSubject := s.route(path)
+	rest := strings.TrimPrefix(path, routePath)
+	return joinSubject(s.Prefix, routeSubject, pathToSubject(rest))
+}
+
+// subscribeSubject returns the wildcard subject of the route path requests.
+func (s Subjects) subscribeSubject(routePath string) string {
+	return s.Subject(routePath) + ".>"
+}
+
+// route returns the longest overridden route path the path belongs to, and its subject.
+func (s Subjects) route(path string) (string, string) {
+	var routePath, routeSubject string
+	for prefix, subject := range s.Routes {
+		prefix = "/" + strings.Trim(prefix, "/") + "/"
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(routePath) {
+			routePath, routeSubject = prefix, subject
+		}
+	}
+	return routePath, routeSubject
+}
+
+// subjectToURL maps the request subject received by the subscribePath subscription back to the handler path URL.
+func subjectToURL(subj, subscribePath, handlerPath string) string {
+	rest := strings.TrimPrefix(subj, strings.TrimSuffix(subscribePath, ">"))
+	return strings.TrimSuffix(handlerPath, "/") + "/" + strings.ReplaceAll(rest, ".", "/")
+}
+
+func pathToSubject(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", ".")
+}
+
+// joinSubject joins the non-empty subject tokens with dots.
+func joinSubject(tokens ...string) string {
+	parts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if token = strings.Trim(token, "."); token != "" {
+			parts = append(parts, token)
+		}
+	}
+	return strings.Join(parts, ".")
+}
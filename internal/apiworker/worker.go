@@ -7,12 +7,28 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
+	"sync/atomic"
+	"time"
 
+	"connectrpc.com/connect"
 	"github.com/nats-io/nats.go"
-	"google.golang.org/protobuf/encoding/protojson"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/nats-io/nuid"
 
+	"github.com/leonardinius/go-service-template/internal/apierrors"
 	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/health"
+	"github.com/leonardinius/go-service-template/internal/insights"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+)
+
+const (
+	connectPollInterval = 100 * time.Millisecond
+	drainPollInterval   = 100 * time.Millisecond
+	// serverShutdownTimeout bounds the metrics server shutdown, which runs after the drain.
+	serverShutdownTimeout = 5 * time.Second
 )
 
 type Worker interface {
@@ -25,112 +41,467 @@ type worker struct {
 	handler http.Handler
 	natsCon *nats.Conn
 	routes  []apiserv.Route
+	config  *natsOptions
+	// consumers are JetStream consume contexts, set in JetStream mode only.
+	consumers []jetstream.ConsumeContext
+	// service is the NATS.io micro service, set if configured with WithMicroService.
+	service micro.Service
+	metrics *workerMetrics
+	health  workerHealth
+	// embedded is the in-process NATS.io server, set if configured with WithEmbeddedServer.
+	embedded *natsserver.Server
+	// subs are the core NATS.io route subscriptions.
+	subs []*nats.Subscription
+	// instance is the worker instance ID, see WithAdmin.
+	instance string
+	inFlight atomic.Int64
+	// background is the number of messages handled in background, see concurrentMsgHandler.
+	background atomic.Int64
+	draining   atomic.Bool
+	// closed is closed once the connection is closed, e.g. when the drain is complete.
+	closed chan struct{}
+	// registry is the health registry of the served routes and of the worker checks, see health.RegistryFromContext.
+	registry *health.Registry
 }
 
 var _ Worker = (*worker)(nil)
 
+// NewWorker creates the worker serving routes over NATS.io.
+// The serverURL is used unless the server URLs are set with WithURL or WithContextName,
+// or the worker runs the embedded server, see WithEmbeddedServer.
 func NewWorker(ctx context.Context, serverURL string, routes []apiserv.Route, options ...Option) (Worker, error) {
 	config, natsioOptions, err := buildNatsIOOptions(ctx, options...)
 	if err != nil {
 		return nil, err
 	}
+	if config.url != "" {
+		serverURL = config.url
+	}
 
-	server, err := apiserv.NewDefaultServer(ctx, config.metricsAddress, routes...)
+	server, err := apiserv.NewDefaultServer(ctx, config.metricsAddress, routes)
 	if err != nil {
 		return nil, err
 	}
 
-	// save original originalHandler
-	originalHandler := server.Handler
-	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != apiserv.MetricsRoutePath {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte("Use NATS.io for rpc invocation. Metrics are available at " + apiserv.MetricsRoutePath + "."))
-			return
+	metrics, err := newWorkerMetrics(insights.RegistrerFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded *natsserver.Server
+	if config.embedded != nil {
+		if embedded, err = startEmbeddedServer(ctx, config.embedded); err != nil {
+			metrics.close()
+			return nil, errors.Join(err, server.Shutdown(context.WithoutCancel(ctx)))
 		}
-		originalHandler.ServeHTTP(w, r)
-	})
+		serverURL = embedded.ClientURL()
+	}
+
+	closed := make(chan struct{})
+	natsioOptions = append(natsioOptions, nats.ClosedHandler(func(*nats.Conn) {
+		slog.LogAttrs(ctx, slog.LevelInfo, "natsio connection", slog.String("event", "closed"))
+		close(closed)
+	}))
 
 	natsCon, err := nats.Connect(serverURL, natsioOptions...)
 	if err != nil {
+		metrics.close()
 		if shutdownErr := server.Shutdown(context.WithoutCancel(ctx)); shutdownErr != nil {
 			err = errors.Join(err, fmt.Errorf("failed to shutdown metrics server: %w", shutdownErr))
 		}
+		if embedded != nil {
+			embedded.Shutdown()
+		}
 		return nil, err
 	}
+	metrics.setConnection(natsCon)
 
-	return &worker{
-		server,
-		originalHandler,
-		natsCon,
-		routes,
-	}, nil
+	wrk := &worker{
+		server:   server,
+		handler:  server.Handler,
+		natsCon:  natsCon,
+		routes:   routes,
+		config:   config,
+		metrics:  metrics,
+		embedded: embedded,
+		instance: nuid.Next(),
+		closed:   closed,
+		registry: health.RegistryFromContext(ctx),
+	}
+	wrk.registerHealthChecks()
+	// the metrics listener serves metrics and probes only
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case apiserv.MetricsRoutePath, HealthzRoutePath, ReadyzRoutePath:
+			wrk.handler.ServeHTTP(w, r)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("Use NATS.io for rpc invocation. Metrics are available at " + apiserv.MetricsRoutePath +
+				", probes at " + HealthzRoutePath + " and " + ReadyzRoutePath + "."))
+		}
+	})
+	return wrk, nil
 }
 
 // ListenAndServe implements Worker.
+// The requests are served with the ctx values, but the ctx cancellation does not cancel them:
+// the requests in flight complete during Shutdown, only the request deadline cancels them.
 func (w *worker) ListenAndServe(ctx context.Context) error {
+	serveCtx := context.WithoutCancel(ctx)
 	var err error
-	// Subscribe to NATS.io messages.
-	for _, route := range w.routes {
-		subscribePath := urlToSuscribeSubject(route.Path())
-		msgHandler := w.natsMsgHandler(ctx, subscribePath, route.Path())
-		_, routeErr := w.natsCon.Subscribe(subscribePath, msgHandler)
-		slog.LogAttrs(ctx, slog.LevelInfo, "nats subscribe error", slog.String("subject", subscribePath))
-		err = errors.Join(err, routeErr)
+	if w.config.admin != "" {
+		if err = w.subscribeAdmin(serveCtx); err != nil {
+			return err
+		}
+	}
+	switch {
+	case w.config.jetstream != nil:
+		// JetStream streams and consumers are created over the connection
+		if err = w.waitConnected(ctx); err != nil {
+			return err
+		}
+		err = w.subscribeJetStream(serveCtx)
+	case w.config.micro != nil:
+		err = w.subscribeMicro(serveCtx)
+	default:
+		err = w.subscribe(serveCtx)
 	}
 	if err != nil {
 		return err
 	}
+	// Make sure subscriptions are registered on the server before serving.
+	// Until connected, see WithRetryOnFailedConnect, subscriptions are registered on connect.
+	if w.natsCon.IsConnected() {
+		if err = w.natsCon.Flush(); err != nil {
+			return err
+		}
+	}
+	w.health.subscribed.Store(true)
+	w.registry.SetServing(health.StatusServing, w.routePaths()...)
+
+	return apiserv.ListenAndServe(serveCtx, w.server)
+}
+
+// waitConnected waits for the initial connection if the worker started without a server, see WithRetryOnFailedConnect.
+func (w *worker) waitConnected(ctx context.Context) error {
+	poll := time.NewTicker(connectPollInterval)
+	defer poll.Stop()
+	for !w.natsCon.IsConnected() {
+		if w.natsCon.IsClosed() {
+			return nats.ErrConnectionClosed
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-poll.C:
+		}
+	}
+	return nil
+}
+
+// subscribe subscribes to NATS.io messages of every route.
+// Messages are handled concurrently up to the in-flight limit, see WithMaxInFlight.
+// Once the limit is reached, messages are buffered by the subscription up to the pending limits, see WithPendingLimits.
+func (w *worker) subscribe(ctx context.Context) error {
+	var err error
+	for _, route := range w.routes {
+		subscribePath := w.config.subjects.subscribeSubject(route.Path())
+		msgHandler := concurrentMsgHandler(w, subscribePath, w.natsMsgHandler(ctx, subscribePath, route.Path()))
+		w.health.dispatch(subscribePath)
+		sub, routeErr := w.natsCon.QueueSubscribe(subscribePath, w.config.queueGroup, msgHandler)
+		if routeErr == nil {
+			routeErr = w.setPendingLimits(sub)
+			w.metrics.addSubscription(subscribePath, sub)
+			w.subs = append(w.subs, sub)
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "nats subscribe",
+			slog.String("subject", subscribePath),
+			slog.String("queue", w.config.queueGroup),
+			slog.Int("max_inflight", w.concurrency()))
+		err = errors.Join(err, routeErr)
+	}
+	return err
+}
 
-	return apiserv.ListenAndServe(ctx, w.server)
+// concurrentMsgHandler runs the handler in background, blocking the subscription (or the JetStream consume loop)
+// while the in-flight limit is reached. The blocked message is counted as a pending message of the subject,
+// see workerMetrics.pending.
+func concurrentMsgHandler[M any](w *worker, subject string, handler func(M)) func(M) {
+	slots := make(chan struct{}, w.concurrency())
+	return func(msg M) {
+		w.metrics.addWaiting(subject, 1)
+		slots <- struct{}{}
+		w.metrics.addWaiting(subject, -1)
+		w.background.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				w.background.Add(-1)
+			}()
+			handler(msg)
+		}()
+	}
+}
+
+func (w *worker) routePaths() []string {
+	paths := make([]string, 0, len(w.routes))
+	for _, route := range w.routes {
+		paths = append(paths, route.Path())
+	}
+	return paths
+}
+
+// concurrency returns the maximum number of requests handled concurrently per route.
+func (w *worker) concurrency() int {
+	return max(w.config.maxInFlight, 1)
+}
+
+func (w *worker) setPendingLimits(sub *nats.Subscription) error {
+	if w.config.pendingMsgs == 0 && w.config.pendingBytes == 0 {
+		return nil
+	}
+	msgs, bytes := w.config.pendingMsgs, w.config.pendingBytes
+	if msgs == 0 {
+		msgs = nats.DefaultSubPendingMsgsLimit
+	}
+	if bytes == 0 {
+		bytes = nats.DefaultSubPendingBytesLimit
+	}
+	return sub.SetPendingLimits(msgs, bytes)
+}
+
+// drainRoutes stops receiving route requests, requests in flight are completed.
+// The connection and the admin subscription are kept.
+func (w *worker) drainRoutes() error {
+	if !w.draining.CompareAndSwap(false, true) {
+		return nil
+	}
+	w.health.subscribed.Store(false)
+	w.registry.SetServing(health.StatusNotServing, w.routePaths()...)
+	var err error
+	for _, sub := range w.subs {
+		err = errors.Join(err, sub.Drain())
+	}
+	for _, consumer := range w.consumers {
+		consumer.Drain()
+	}
+	if w.service != nil && !w.service.Stopped() {
+		err = errors.Join(err, w.service.Stop())
+	}
+	return err
 }
 
 // Shutdown implements Worker.
+// Route requests stop first, then the requests in flight are completed and the connection is drained
+// up to the drain timeout, see WithDrainTimeout. Requests still in flight are abandoned.
+// The metrics server is stopped last.
 func (w *worker) Shutdown(ctx context.Context) error {
-	var err error
+	drainCtx, cancel := context.WithTimeout(ctx, w.config.drainTimeout)
+	defer cancel()
+
+	err := w.drainRoutes()
 	if w.natsCon != nil && !w.natsCon.IsClosed() {
-		err = errors.Join(err, w.natsCon.Drain())
+		w.waitInFlight(drainCtx)
+		// the drain flushes the replies and closes the connection, see the closed handler
+		if drainErr := w.natsCon.Drain(); drainErr == nil {
+			select {
+			case <-w.closed:
+			case <-drainCtx.Done():
+			}
+		} else {
+			err = errors.Join(err, drainErr)
+		}
+		if abandoned := w.inFlight.Load(); abandoned > 0 {
+			w.metrics.abandoned.Add(float64(abandoned))
+			slog.LogAttrs(ctx, slog.LevelWarn, "nats shutdown abandoned requests in flight",
+				slog.Int64("abandoned", abandoned),
+				slog.Duration("drain_timeout", w.config.drainTimeout))
+		}
 		w.natsCon.Close()
 	}
+	if w.embedded != nil {
+		w.embedded.Shutdown()
+		w.embedded.WaitForShutdown()
+	}
 	if w.server != nil {
-		err = errors.Join(err, w.server.Shutdown(context.WithoutCancel(ctx)))
+		serverCtx, serverCancel := context.WithTimeout(context.WithoutCancel(ctx), serverShutdownTimeout)
+		defer serverCancel()
+		err = errors.Join(err, w.server.Shutdown(serverCtx))
 	}
+	w.metrics.close()
 	return err
 }
 
+// waitInFlight waits for the route subscriptions to drain and for the messages handled in background to be replied.
+// The subscriptions drain does not track the messages handled in background, see concurrentMsgHandler.
+func (w *worker) waitInFlight(ctx context.Context) {
+	poll := time.NewTicker(drainPollInterval)
+	defer poll.Stop()
+	for !w.routesDrained() || w.background.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// routesDrained reports whether the route subscriptions and JetStream consumers delivered all their messages.
+func (w *worker) routesDrained() bool {
+	for _, sub := range w.subs {
+		if sub.IsValid() {
+			return false
+		}
+	}
+	for _, consumer := range w.consumers {
+		select {
+		case <-consumer.Closed():
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func (w *worker) natsMsgHandler(ctx context.Context, subscribePath, handlerPath string) nats.MsgHandler {
 	return func(msg *nats.Msg) {
-		req, err := NewRequestFromMessage(msg, subscribePath, handlerPath)
-		if err != nil {
-			gwErrror := errToSharedError(err)
-			data, errRespond := protojson.Marshal(gwErrror)
-			errRespond = errors.Join(errRespond, msg.Respond(data))
-			slog.LogAttrs(ctx, slog.LevelError,
-				"NATS.io to gPRC request error",
-				slog.String("subject", msg.Subject),
-				slog.String("error", errRespond.Error()),
-				slog.String("parent_error", err.Error()),
-			)
-		}
-		req = req.WithContext(ctx)
-		buffer := bytes.NewBufferString("")
-		resp := NewStdResponseWriter(buffer)
+		w.serveMsg(ctx, msg, subscribePath, handlerPath, func(reply *nats.Msg, _ int) {
+			if err := msg.RespondMsg(reply); err != nil {
+				slog.LogAttrs(ctx, slog.LevelError,
+					"NATS.io to gPRC response error",
+					slog.String("subject", msg.Subject),
+					slog.String("error", err.Error()),
+				)
+			}
+		})
+	}
+}
 
-		w.handler.ServeHTTP(resp, req)
-		resp.Header().Set("X-Status-Code", strconv.Itoa(resp.statusCode))
+// serveMsg invokes the HTTP handler for the given message and passes the reply message and HTTP status code to respond.
+// It returns once the handler returns, which may be after respond is called, see serveRequest.
+// The request is traced as a child of the messaging consumer span, see startConsumerSpan.
+func (w *worker) serveMsg(
+	ctx context.Context,
+	msg *nats.Msg,
+	subscribePath, handlerPath string,
+	respond func(reply *nats.Msg, statusCode int),
+) {
+	req, err := NewRequestFromMessage(msg, subscribePath, handlerPath)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError,
+			"NATS.io to gPRC request error",
+			slog.String("subject", msg.Subject),
+			slog.String("error", err.Error()),
+		)
+		respond(newErrorReplyMsg(ctx, msg, apierrors.Wrap(connect.CodeInvalidArgument, err)))
+		return
+	}
+
+	w.health.dispatch(subscribePath)
+	inFlight := w.metrics.inFlight.WithLabelValues(subscribePath)
+	inFlight.Inc()
+	w.inFlight.Add(1)
+	defer func() {
+		inFlight.Dec()
+		w.inFlight.Add(-1)
+	}()
+
+	ctx, span := w.startConsumerSpan(ctx, msg, req)
+	w.serveRequest(ctx, msg, req, func(reply *nats.Msg, statusCode int) {
+		endConsumerSpan(ctx, span, reply, statusCode)
+		respond(reply, statusCode)
+	})
+}
 
-		err = msg.RespondMsg(&nats.Msg{
-			Subject: msg.Subject,
-			Reply:   msg.Reply,
-			Data:    buffer.Bytes(),
-			Header:  nats.Header(resp.Header()),
+// serveRequest runs the HTTP handler with the request deadline, see newRequestDeadline.
+// Expired requests are answered with the deadline exceeded error without waiting for the handler to return,
+// the error ends the reply stream, if the handler has streamed the response.
+// serveRequest still returns once the handler returns, so the abandoned handler keeps its in-flight slot.
+func (w *worker) serveRequest(ctx context.Context, msg *nats.Msg, req *http.Request, respond func(*nats.Msg, int)) {
+	stream := msg.Reply != "" && isStreamRequest(req)
+	deadline := newRequestDeadline(ctx, req.Header, stream, w.config.defaultTimeout, w.config.maxTimeout)
+	defer deadline.stop()
+	req = req.WithContext(deadline.ctx)
+
+	buffer := bytes.NewBufferString("")
+	resp := NewStdResponseWriter(buffer)
+	// reflect the request codec
+	resp.defaultContentType = req.Header.Get("Content-Type")
+	var writer *streamResponseWriter
+	if stream {
+		writer = newStreamResponseWriter(resp, buffer, msg.Reply, func(m *nats.Msg) error {
+			if err := w.natsCon.PublishMsg(m); err != nil {
+				return err
+			}
+			deadline.extend()
+			return nil
 		})
-		if err != nil {
+	}
+
+	var (
+		reply      *nats.Msg
+		statusCode int
+		done       = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		reply, statusCode = w.serveHTTP(ctx, msg, req, resp, buffer, writer)
+	}()
+
+	select {
+	case <-done:
+	case <-deadline.ctx.Done():
+		if !deadline.exceeded() {
+			// canceled by the caller context rather than the deadline, let the handler finish
+			<-done
+			break
+		}
+		slog.LogAttrs(ctx, slog.LevelWarn,
+			"NATS.io to gPRC request deadline exceeded",
+			slog.String("subject", msg.Subject),
+		)
+		reply, statusCode := newErrorReplyMsg(ctx, msg, context.DeadlineExceeded)
+		if writer != nil {
+			writer.abort(reply, connect.CodeDeadlineExceeded)
+		}
+		respond(reply, statusCode)
+		<-done
+		return
+	}
+
+	respond(reply, statusCode)
+}
+
+// serveHTTP invokes the HTTP handler for the request, the stream writer is set for the streamed response.
+func (w *worker) serveHTTP(
+	ctx context.Context,
+	msg *nats.Msg,
+	req *http.Request,
+	resp *stdResponseWriter,
+	buffer *bytes.Buffer,
+	writer *streamResponseWriter,
+) (*nats.Msg, int) {
+	if writer != nil {
+		w.handler.ServeHTTP(writer, req)
+		if writer.err != nil {
 			slog.LogAttrs(ctx, slog.LevelError,
-				"NATS.io to gPRC response error",
+				"NATS.io to gPRC stream response error",
 				slog.String("subject", msg.Subject),
-				slog.String("error", err.Error()),
+				slog.String("error", writer.err.Error()),
 			)
 		}
+		reply := writer.endMsg()
+		reply.Subject = msg.Subject
+		reply.Reply = msg.Reply
+		return reply, resp.status()
 	}
+
+	w.handler.ServeHTTP(resp, req)
+
+	return &nats.Msg{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    buffer.Bytes(),
+		Header:  resp.replyHeader(),
+	}, resp.status()
 }
@@ -0,0 +1,137 @@
+package apiworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/health"
+)
+
+const (
+	// HealthzRoutePath is the worker liveness probe path on the metrics listener.
+	HealthzRoutePath = apiserv.HealthzRoutePath
+	// ReadyzRoutePath is the worker readiness probe path on the metrics listener.
+	ReadyzRoutePath = apiserv.ReadyzRoutePath
+
+	// DefaultDispatchStuckTimeout is the default time after which a route with pending messages
+	// and no dispatched requests is stuck, see WithDispatchStuckTimeout.
+	DefaultDispatchStuckTimeout = time.Minute
+)
+
+var errNotSubscribed = errors.New("routes are not subscribed")
+
+type workerHealth struct {
+	// subscribed is set once all route subscriptions succeeded.
+	subscribed atomic.Bool
+
+	m sync.Mutex
+	// dispatched is the last request dispatch time per subject.
+	dispatched map[string]time.Time
+	// serving is the number of requests handled by the subscription callback per subject, see microHandler.
+	serving map[string]int
+}
+
+// dispatch records the subject message dispatch, see WithDispatchStuckTimeout.
+func (h *workerHealth) dispatch(subject string) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if h.dispatched == nil {
+		h.dispatched = make(map[string]time.Time)
+	}
+	h.dispatched[subject] = time.Now()
+}
+
+// serve records the subject request handled by the subscription callback until the returned done is called.
+// The subscription does not dispatch the next message meanwhile, see checkDispatch.
+func (h *workerHealth) serve(subject string) (done func()) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if h.serving == nil {
+		h.serving = make(map[string]int)
+	}
+	h.serving[subject]++
+	return func() {
+		h.m.Lock()
+		defer h.m.Unlock()
+		h.serving[subject]--
+	}
+}
+
+// servingSubjects returns the subjects with requests handled by the subscription callback.
+func (h *workerHealth) servingSubjects() []string {
+	h.m.Lock()
+	defer h.m.Unlock()
+	subjects := make([]string, 0, len(h.serving))
+	for subject, serving := range h.serving {
+		if serving > 0 {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects
+}
+
+func (h *workerHealth) lastDispatch(subject string) time.Time {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return h.dispatched[subject]
+}
+
+// registerHealthChecks registers the worker checks in the health registry.
+//
+// The worker is alive while the NATS.io connection is not closed, and routes with pending messages
+// keep dispatching requests. The worker is ready while the NATS.io connection is connected (not reconnecting
+// or draining), and all route subscriptions succeeded.
+func (w *worker) registerHealthChecks() {
+	w.registry.RegisterLiveness("nats", health.CheckerFunc(func(context.Context) error {
+		if status := w.natsCon.Status(); status == nats.CLOSED {
+			return fmt.Errorf("nats connection is %s", status)
+		}
+		return nil
+	}))
+	w.registry.RegisterLiveness("dispatch", health.CheckerFunc(w.checkDispatch))
+
+	w.registry.RegisterReadiness("nats", health.CheckerFunc(func(context.Context) error {
+		if status := w.natsCon.Status(); status != nats.CONNECTED {
+			return fmt.Errorf("nats connection is %s", status)
+		}
+		return nil
+	}))
+	w.registry.RegisterReadiness("subscriptions", health.CheckerFunc(func(context.Context) error {
+		if !w.health.subscribed.Load() {
+			return errNotSubscribed
+		}
+		return nil
+	}))
+}
+
+// checkDispatch fails if any route has pending messages, but dispatched no requests for too long.
+// The pending messages are buffered by the route subscription, or wait for the in-flight slot, see workerMetrics.pending.
+// The micro service endpoints do not report the pending messages, so the endpoint handling a request
+// for too long is stuck, see microHandler.
+func (w *worker) checkDispatch(context.Context) error {
+	var stuck []string
+	for subject, pending := range w.metrics.pending() {
+		idle := time.Since(w.health.lastDispatch(subject)).Truncate(time.Second)
+		if pending > 0 && idle > w.config.dispatchStuckTimeout {
+			stuck = append(stuck, fmt.Sprintf("%s: %d pending messages, no requests dispatched for %s", subject, pending, idle))
+		}
+	}
+	for _, subject := range w.health.servingSubjects() {
+		idle := time.Since(w.health.lastDispatch(subject)).Truncate(time.Second)
+		if idle > w.config.dispatchStuckTimeout {
+			stuck = append(stuck, fmt.Sprintf("%s: request in flight, no requests dispatched for %s", subject, idle))
+		}
+	}
+	if len(stuck) > 0 {
+		return errors.New(strings.Join(stuck, "; "))
+	}
+	return nil
+}
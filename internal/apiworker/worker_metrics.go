@@ -0,0 +1,282 @@
+package apiworker
+
+import (
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/leonardinius/go-service-template/internal/insights"
+)
+
+const metricsNamespace = "nats_worker"
+
+type workerMetrics struct {
+	inFlight  *prometheus.GaugeVec
+	abandoned prometheus.Counter
+
+	m    sync.Mutex
+	subs map[string][]*nats.Subscription
+	// waiting is the number of messages waiting for the in-flight slot per subject, see concurrentMsgHandler.
+	waiting map[string]int
+	conn    *nats.Conn
+
+	// collectors are the shared collectors reporting the worker state, see close.
+	collectors []*workerSet
+}
+
+func newWorkerMetrics(registerer prometheus.Registerer) (*workerMetrics, error) {
+	metrics := &workerMetrics{
+		subs:    make(map[string][]*nats.Subscription),
+		waiting: make(map[string]int),
+	}
+
+	inFlight, err := insights.RegisterCollector(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "inflight_requests",
+		Help:      "Number of requests being handled.",
+	}, []string{"subject"}))
+	if err != nil {
+		return nil, err
+	}
+	metrics.inFlight = inFlight
+
+	abandoned, err := insights.RegisterCollector(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "abandoned_requests_total",
+		Help:      "Number of requests still in flight when the shutdown deadline expired.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	metrics.abandoned = abandoned
+
+	pending := prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "pending_messages"),
+		"Number of messages delivered to the subscriptions, but not yet handled.", []string{"subject"}, nil)
+	dropped := prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "dropped_messages_total"),
+		"Number of messages dropped by the subscriptions because of the pending limits (slow consumer).",
+		[]string{"subject"}, nil)
+	subscriptions, err := insights.RegisterCollector(registerer, &subscriptionsCollector{pending: pending, dropped: dropped})
+	if err != nil {
+		return nil, err
+	}
+	connections, err := insights.RegisterCollector(registerer, newConnectionCollector())
+	if err != nil {
+		return nil, err
+	}
+	for _, workers := range []*workerSet{&subscriptions.workers, &connections.workers} {
+		workers.add(metrics)
+		metrics.collectors = append(metrics.collectors, workers)
+	}
+
+	return metrics, nil
+}
+
+// close removes the worker from the shared collectors, the worker state is not reported anymore.
+func (m *workerMetrics) close() {
+	for _, workers := range m.collectors {
+		workers.remove(m)
+	}
+}
+
+// addSubscription adds the subscription to the pending and dropped messages metrics.
+func (m *workerMetrics) addSubscription(subject string, sub *nats.Subscription) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.subs[subject] = append(m.subs[subject], sub)
+}
+
+// addWaiting adds delta to the number of messages waiting for the in-flight slot.
+func (m *workerMetrics) addWaiting(subject string, delta int) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.waiting[subject] += delta
+}
+
+// setConnection adds the connection to the connection state metrics.
+func (m *workerMetrics) setConnection(conn *nats.Conn) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.conn = conn
+}
+
+// pending returns the number of pending messages per subject: the messages buffered by the subscriptions
+// and the messages waiting for the in-flight slot.
+func (m *workerMetrics) pending() map[string]int {
+	m.m.Lock()
+	defer m.m.Unlock()
+	pending := make(map[string]int, len(m.waiting))
+	for subject, waiting := range m.waiting {
+		pending[subject] = waiting
+	}
+	for subject, subs := range m.subs {
+		for _, sub := range subs {
+			if msgs, _, err := sub.Pending(); err == nil {
+				pending[subject] += msgs
+			}
+		}
+	}
+	return pending
+}
+
+// dropped returns the number of messages dropped by the subscriptions per subject.
+func (m *workerMetrics) dropped() map[string]int {
+	m.m.Lock()
+	defer m.m.Unlock()
+	dropped := make(map[string]int, len(m.subs))
+	for subject, subs := range m.subs {
+		dropped[subject] = 0
+		for _, sub := range subs {
+			if msgs, err := sub.Dropped(); err == nil {
+				dropped[subject] += msgs
+			}
+		}
+	}
+	return dropped
+}
+
+// workerSet is the set of the workers reported by the shared collector, e.g. of the restarted worker
+// in the same registry, see insights.RegisterCollector.
+type workerSet struct {
+	m       sync.Mutex
+	metrics map[*workerMetrics]struct{}
+}
+
+func (s *workerSet) add(metrics *workerMetrics) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.metrics == nil {
+		s.metrics = make(map[*workerMetrics]struct{})
+	}
+	s.metrics[metrics] = struct{}{}
+}
+
+func (s *workerSet) remove(metrics *workerMetrics) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.metrics, metrics)
+}
+
+func (s *workerSet) list() []*workerMetrics {
+	s.m.Lock()
+	defer s.m.Unlock()
+	list := make([]*workerMetrics, 0, len(s.metrics))
+	for metrics := range s.metrics {
+		list = append(list, metrics)
+	}
+	return list
+}
+
+// subscriptionsCollector reports the pending and dropped messages summed up by subject over the workers.
+type subscriptionsCollector struct {
+	workers workerSet
+	pending *prometheus.Desc
+	dropped *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector.
+func (c *subscriptionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pending
+	ch <- c.dropped
+}
+
+// Collect implements prometheus.Collector.
+func (c *subscriptionsCollector) Collect(ch chan<- prometheus.Metric) {
+	pending := make(map[string]int)
+	dropped := make(map[string]int)
+	for _, metrics := range c.workers.list() {
+		for subject, msgs := range metrics.pending() {
+			pending[subject] += msgs
+		}
+		for subject, msgs := range metrics.dropped() {
+			dropped[subject] += msgs
+		}
+	}
+	for subject, msgs := range pending {
+		ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(msgs), subject)
+	}
+	for subject, msgs := range dropped {
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(msgs), subject)
+	}
+}
+
+// connectionStatuses are the reported connection statuses, exactly one of them is set at a time.
+var connectionStatuses = []nats.Status{
+	nats.CONNECTING,
+	nats.CONNECTED,
+	nats.RECONNECTING,
+	nats.DISCONNECTED,
+	nats.DRAINING_SUBS,
+	nats.DRAINING_PUBS,
+	nats.CLOSED,
+}
+
+// connectionCollector reports the connection status and statistics summed up over the workers.
+type connectionCollector struct {
+	workers    workerSet
+	status     *prometheus.Desc
+	reconnects *prometheus.Desc
+	inMsgs     *prometheus.Desc
+	outMsgs    *prometheus.Desc
+	inBytes    *prometheus.Desc
+	outBytes   *prometheus.Desc
+}
+
+func newConnectionCollector() *connectionCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "connection", name), help, labels, nil)
+	}
+	return &connectionCollector{
+		status:     desc("status", "NATS.io connection status, the number of connections in the status.", "status"),
+		reconnects: desc("reconnects_total", "Number of NATS.io reconnects."),
+		inMsgs:     desc("in_messages_total", "Number of messages received over the NATS.io connection."),
+		outMsgs:    desc("out_messages_total", "Number of messages sent over the NATS.io connection."),
+		inBytes:    desc("in_bytes_total", "Number of bytes received over the NATS.io connection."),
+		outBytes:   desc("out_bytes_total", "Number of bytes sent over the NATS.io connection."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *connectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.status
+	ch <- c.reconnects
+	ch <- c.inMsgs
+	ch <- c.outMsgs
+	ch <- c.inBytes
+	ch <- c.outBytes
+}
+
+// Collect implements prometheus.Collector.
+func (c *connectionCollector) Collect(ch chan<- prometheus.Metric) {
+	statuses := make(map[nats.Status]int, len(connectionStatuses))
+	var stats nats.Statistics
+	connected := false
+	for _, metrics := range c.workers.list() {
+		metrics.m.Lock()
+		conn := metrics.conn
+		metrics.m.Unlock()
+		if conn == nil {
+			continue
+		}
+		connected = true
+		statuses[conn.Status()]++
+		connStats := conn.Stats()
+		stats.Reconnects += connStats.Reconnects
+		stats.InMsgs += connStats.InMsgs
+		stats.OutMsgs += connStats.OutMsgs
+		stats.InBytes += connStats.InBytes
+		stats.OutBytes += connStats.OutBytes
+	}
+	if !connected {
+		return
+	}
+
+	for _, status := range connectionStatuses {
+		ch <- prometheus.MustNewConstMetric(c.status, prometheus.GaugeValue, float64(statuses[status]), status.String())
+	}
+	ch <- prometheus.MustNewConstMetric(c.reconnects, prometheus.CounterValue, float64(stats.Reconnects))
+	ch <- prometheus.MustNewConstMetric(c.inMsgs, prometheus.CounterValue, float64(stats.InMsgs))
+	ch <- prometheus.MustNewConstMetric(c.outMsgs, prometheus.CounterValue, float64(stats.OutMsgs))
+	ch <- prometheus.MustNewConstMetric(c.inBytes, prometheus.CounterValue, float64(stats.InBytes))
+	ch <- prometheus.MustNewConstMetric(c.outBytes, prometheus.CounterValue, float64(stats.OutBytes))
+}
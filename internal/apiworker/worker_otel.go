@@ -0,0 +1,74 @@
+package apiworker
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/leonardinius/go-service-template/internal/insights"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+const (
+	instrumentationName = "github.com/leonardinius/go-service-template/internal/apiworker"
+	messagingSystem     = "nats"
+	processOperation    = "process"
+)
+
+// startConsumerSpan starts the messaging consumer span for the message.
+// The span continues the caller trace from the message headers and is injected into the request headers,
+// so it becomes the parent of the HTTP and Connect spans.
+func (w *worker) startConsumerSpan(ctx context.Context, msg *nats.Msg, req *http.Request) (context.Context, trace.Span) {
+	props := otel.GetTextMapPropagator()
+	ctx = props.Extract(ctx, propagation.HeaderCarrier(req.Header))
+
+	attrs := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(messagingSystem),
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingOperationName(processOperation),
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		),
+	}
+	if group := w.consumerGroup(); group != "" {
+		attrs = append(attrs, trace.WithAttributes(semconv.MessagingConsumerGroupName(group)))
+	}
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, processOperation+" "+msg.Subject, attrs...)
+
+	props.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return insights.ContextWithTrustedTrace(ctx), span
+}
+
+// endConsumerSpan ends the messaging consumer span and injects the trace context into the reply,
+// unless the handler already did.
+func endConsumerSpan(ctx context.Context, span trace.Span, reply *nats.Msg, statusCode int) {
+	span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+	span.End()
+
+	if reply.Header == nil {
+		reply.Header = nats.Header{}
+	}
+	header := http.Header(reply.Header)
+	if header.Get("Traceparent") == "" {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+	}
+}
+
+// consumerGroup returns the queue group or the JetStream durable name the worker consumes messages with.
+func (w *worker) consumerGroup() string {
+	if w.config.jetstream != nil {
+		return w.config.jetstream.durable
+	}
+	return w.config.queueGroup
+}
@@ -0,0 +1,188 @@
+// Package devcerts generates the development certificates: the local CA, the server certificates
+// for the given SANs and the client certificates. They are not meant for production use.
+package devcerts
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultValidFor is the default certificate validity.
+	DefaultValidFor = 365 * 24 * time.Hour
+	// Organization is the subject organization of the generated certificates.
+	Organization = "go-service-template development"
+
+	// clockSkew backdates the certificates, so they are valid on the hosts with the clock behind.
+	clockSkew = time.Hour
+)
+
+var (
+	errNotCA        = errors.New("the certificate is not a CA")
+	errIncompleteCA = errors.New("the CA certificate or key file is missing")
+)
+
+// DefaultSANs are the default server certificate SANs, the local host names and addresses.
+func DefaultSANs() []string {
+	return []string{"localhost", "127.0.0.1", "::1"}
+}
+
+// Cert is the certificate with its private key.
+type Cert struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA returns the self-signed CA certificate.
+func NewCA(commonName string, validFor time.Duration) (*Cert, error) {
+	template := newTemplate(commonName, validFor)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	return issue(template, nil)
+}
+
+// LoadCA returns the CA certificate of the PEM certificate and key files, e.g. written by WriteFiles.
+// The error wraps os.ErrNotExist only if both files are missing, so the new CA does not replace the half of the existing one.
+func LoadCA(certFile, keyFile string) (*Cert, error) {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if certMissing, keyMissing := errors.Is(certErr, os.ErrNotExist), errors.Is(keyErr, os.ErrNotExist); certMissing != keyMissing {
+		missing := certFile
+		if keyMissing {
+			missing = keyFile
+		}
+		return nil, fmt.Errorf("%w: %s", errIncompleteCA, missing)
+	}
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok || !pair.Leaf.IsCA {
+		return nil, errNotCA
+	}
+	return &Cert{Cert: pair.Leaf, Key: signer}, nil
+}
+
+// IssueServer returns the server certificate signed by the CA. The SANs are DNS names or IP addresses,
+// the first one is the subject common name.
+func (c *Cert) IssueServer(sans []string, validFor time.Duration) (*Cert, error) {
+	commonName := "server"
+	if len(sans) > 0 {
+		commonName = sans[0]
+	}
+	template := newTemplate(commonName, validFor)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+	return issue(template, c)
+}
+
+// IssueClient returns the client certificate of the common name signed by the CA.
+func (c *Cert) IssueClient(commonName string, validFor time.Duration) (*Cert, error) {
+	template := newTemplate(commonName, validFor)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	return issue(template, c)
+}
+
+// TLSCertificate returns the certificate for tls.Config.
+func (c *Cert) TLSCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.Cert.Raw}, PrivateKey: c.Key, Leaf: c.Cert}
+}
+
+// CertPool returns the pool of the certificate, e.g. of the CA to verify the issued certificates.
+func (c *Cert) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.Cert)
+	return pool
+}
+
+// CertPEM returns the PEM encoded certificate.
+func (c *Cert) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the PEM encoded PKCS #8 private key.
+func (c *Cert) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles writes the <name>.pem certificate and the <name>-key.pem private key files into the directory.
+// The private key is readable by the owner only. It returns the certificate and key file paths.
+func (c *Cert) WriteFiles(dir, name string) (string, string, error) {
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	key, err := c.KeyPEM()
+	if err != nil {
+		return "", "", err
+	}
+	if err = os.WriteFile(certFile, c.CertPEM(), 0o644); err != nil { //nolint:gosec // the certificate is public
+		return "", "", err
+	}
+	if err = os.WriteFile(keyFile, key, 0o600); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+func newTemplate(commonName string, validFor time.Duration) *x509.Certificate {
+	now := time.Now()
+	return &x509.Certificate{
+		Subject:   pkix.Name{CommonName: commonName, Organization: []string{Organization}},
+		NotBefore: now.Add(-clockSkew),
+		NotAfter:  now.Add(validFor),
+	}
+}
+
+// issue generates the key and signs the certificate with the parent, or self-signs if the parent is nil.
+func issue(template *x509.Certificate, parent *Cert) (*Cert, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer := &Cert{Cert: template, Key: key}
+	if parent != nil {
+		signer = parent
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.Cert, key.Public(), signer.Key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Cert{Cert: cert, Key: key}, nil
+}
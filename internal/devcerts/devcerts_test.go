@@ -0,0 +1,50 @@
+package devcerts_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/devcerts"
+)
+
+func TestLoadCA(t *testing.T) {
+	t.Parallel()
+	// arrange
+	dir := t.TempDir()
+	ca, err := devcerts.NewCA("test-ca", time.Hour)
+	require.NoError(t, err)
+	certFile, keyFile, err := ca.WriteFiles(dir, "ca")
+	require.NoError(t, err)
+
+	// act
+	loaded, err := devcerts.LoadCA(certFile, keyFile)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, ca.Cert.Raw, loaded.Cert.Raw)
+}
+
+func TestLoadCAMissingFiles(t *testing.T) {
+	t.Parallel()
+	// arrange
+	dir := t.TempDir()
+	ca, err := devcerts.NewCA("test-ca", time.Hour)
+	require.NoError(t, err)
+	certFile, keyFile, err := ca.WriteFiles(dir, "ca")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(keyFile))
+
+	// act
+	_, partialErr := devcerts.LoadCA(certFile, keyFile)
+	_, missingErr := devcerts.LoadCA(filepath.Join(dir, "missing.pem"), keyFile)
+
+	// assert: the CA with one of the files missing is not reported as missing, so it is not replaced
+	require.ErrorContains(t, partialErr, keyFile)
+	require.NotErrorIs(t, partialErr, os.ErrNotExist)
+	require.ErrorIs(t, missingErr, os.ErrNotExist)
+}
@@ -0,0 +1,194 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCheckTimeout is the check timeout unless WithTimeout is used.
+	DefaultCheckTimeout = 2 * time.Second
+
+	// CheckStatusOK is the status of the passed check and report.
+	CheckStatusOK = "ok"
+	// CheckStatusFail is the status of the failed check and report.
+	CheckStatusFail = "fail"
+
+	// servingCheck is the readiness check of the Server status.
+	servingCheck = "serving"
+)
+
+// Checker checks the health of a component, e.g. the NATS.io connection or a database. The error fails the check.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is the function Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult is the result of the named check.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	Critical bool   `json:"critical"`
+	Latency  string `json:"latency"`
+}
+
+// Report is the result of the liveness or readiness checks. The status fails if any critical check failed.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// OK reports whether the critical checks passed.
+func (r Report) OK() bool {
+	return r.Status == CheckStatusOK
+}
+
+type check struct {
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+}
+
+// CheckOption configures the registered check.
+type CheckOption interface {
+	apply(c *check)
+}
+
+type checkOptionFunc func(*check)
+
+func (f checkOptionFunc) apply(c *check) {
+	f(c)
+}
+
+// WithTimeout returns a CheckOption that configures the check timeout, the check fails once it expires.
+func WithTimeout(timeout time.Duration) CheckOption {
+	return checkOptionFunc(func(c *check) {
+		c.timeout = timeout
+	})
+}
+
+// WithNonCritical returns a CheckOption that marks the check as non-critical:
+// it is reported, but does not fail the probe, e.g. an optional downstream client.
+func WithNonCritical() CheckOption {
+	return checkOptionFunc(func(c *check) {
+		c.critical = false
+	})
+}
+
+func newCheck(checker Checker, options []CheckOption) *check {
+	c := &check{checker: checker, timeout: DefaultCheckTimeout, critical: true}
+	for _, option := range options {
+		option.apply(c)
+	}
+	return c
+}
+
+// RegisterReadiness registers the named readiness check, it replaces the check registered with the same name.
+// The readiness checks tell whether the component can serve requests, e.g. its dependencies are reachable.
+func (r *Registry) RegisterReadiness(name string, checker Checker, options ...CheckOption) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.readiness[name] = newCheck(checker, options)
+}
+
+// RegisterLiveness registers the named liveness check, it replaces the check registered with the same name.
+// The liveness checks tell whether the process is alive, a failure usually restarts it.
+func (r *Registry) RegisterLiveness(name string, checker Checker, options ...CheckOption) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.liveness[name] = newCheck(checker, options)
+}
+
+// Liveness runs the liveness checks concurrently.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.m.Lock()
+	checks := make(map[string]*check, len(r.liveness))
+	for name, c := range r.liveness {
+		checks[name] = c
+	}
+	r.m.Unlock()
+
+	return runChecks(ctx, checks)
+}
+
+// Readiness runs the readiness checks concurrently.
+// The report has the "serving" check of the Server status, the server is ready once it is serving.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.m.Lock()
+	checks := make(map[string]*check, len(r.readiness))
+	for name, c := range r.readiness {
+		checks[name] = c
+	}
+	status := r.statuses[Server]
+	r.m.Unlock()
+
+	report := runChecks(ctx, checks)
+	result := CheckResult{Status: CheckStatusOK, Message: status.String(), Critical: true, Latency: time.Duration(0).String()}
+	if status != StatusServing {
+		result.Status = CheckStatusFail
+		report.Status = CheckStatusFail
+	}
+	report.Checks[servingCheck] = result
+	return report
+}
+
+func runChecks(ctx context.Context, checks map[string]*check) Report {
+	report := Report{Status: CheckStatusOK, Checks: make(map[string]CheckResult, len(checks)+1)}
+	var (
+		m  sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx)
+			m.Lock()
+			defer m.Unlock()
+			report.Checks[name] = result
+			if c.critical && result.Status != CheckStatusOK {
+				report.Status = CheckStatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs the check until the timeout, the checker which ignores the context is abandoned.
+func (c *check) run(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	started := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.checker.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Status: CheckStatusOK, Critical: c.critical, Latency: time.Since(started).String()}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+	if err != nil {
+		result.Status = CheckStatusFail
+		result.Message = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/health"
+)
+
+func TestReadinessAggregatesChecks(t *testing.T) {
+	t.Parallel()
+	// arrange: the serving registry with the passing and the non-critical failing checks
+	registry := health.NewRegistry()
+	registry.SetServing(health.StatusServing)
+	registry.RegisterReadiness("db", health.CheckerFunc(func(context.Context) error { return nil }))
+	registry.RegisterReadiness("cache", health.CheckerFunc(func(context.Context) error {
+		return errors.New("connection refused")
+	}), health.WithNonCritical())
+
+	// act
+	report := registry.Readiness(t.Context())
+
+	// assert: the non-critical failure is reported, but does not fail the readiness
+	assert.True(t, report.OK())
+	assert.Equal(t, health.CheckStatusOK, report.Checks["db"].Status)
+	assert.Equal(t, health.CheckStatusFail, report.Checks["cache"].Status)
+	assert.Equal(t, "connection refused", report.Checks["cache"].Message)
+	assert.False(t, report.Checks["cache"].Critical)
+	assert.Equal(t, "SERVING", report.Checks["serving"].Message)
+
+	// arrange: the critical check which ignores the context
+	release := make(chan struct{})
+	defer close(release)
+	registry.RegisterReadiness("downstream", health.CheckerFunc(func(context.Context) error {
+		<-release
+		return nil
+	}), health.WithTimeout(50*time.Millisecond))
+
+	// act
+	report = registry.Readiness(t.Context())
+
+	// assert: the check times out and fails the readiness
+	assert.False(t, report.OK())
+	assert.Equal(t, health.CheckStatusFail, report.Checks["downstream"].Status)
+	assert.Equal(t, "timed out after 50ms", report.Checks["downstream"].Message)
+	assert.True(t, report.Checks["downstream"].Critical)
+}
+
+func TestProbeHandlers(t *testing.T) {
+	t.Parallel()
+	// arrange: the live registry, which is not serving yet
+	registry := health.NewRegistry()
+	registry.RegisterLiveness("loop", health.CheckerFunc(func(context.Context) error { return nil }))
+
+	for _, tc := range []struct {
+		handler    http.Handler
+		statusCode int
+		checks     []string
+	}{
+		{registry.LivenessHandler(), http.StatusOK, []string{"loop"}},
+		{registry.ReadinessHandler(), http.StatusServiceUnavailable, []string{"serving"}},
+	} {
+		// act
+		w := httptest.NewRecorder()
+		tc.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/probe", nil))
+
+		// assert: the report JSON with the check latency
+		assert.Equal(t, tc.statusCode, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, tc.statusCode == http.StatusOK, report.OK())
+		for _, name := range tc.checks {
+			require.Contains(t, report.Checks, name)
+			_, err := time.ParseDuration(report.Checks[name].Latency)
+			require.NoError(t, err)
+		}
+	}
+}
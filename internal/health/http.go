@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// LivenessHandler returns the liveness probe handler, e.g. /healthz, see Liveness.
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadinessHandler returns the readiness probe handler, e.g. /readyz, see Readiness.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+// reportHandler writes the report JSON, the response status is 503 Service Unavailable if the report failed.
+func reportHandler(report func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := report(r.Context())
+		statusCode := http.StatusOK
+		if !resp.OK() {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,96 @@
+// Package health is the registry of the service health statuses and of the component health checks.
+// Components, e.g. the HTTP server and the NATS.io worker, update the statuses of the services they serve and
+// register the checks of their dependencies. The /healthz and /readyz probes and the gRPC health service report them.
+package health
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Server is the service name of the overall server status.
+const Server = ""
+
+// Status is the service health status.
+type Status int
+
+const (
+	StatusUnknown Status = iota
+	StatusServing
+	StatusNotServing
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusServing:
+		return "SERVING"
+	case StatusNotServing:
+		return "NOT_SERVING"
+	case StatusUnknown:
+	}
+	return "UNKNOWN"
+}
+
+// Registry is the health statuses by service name and the named liveness and readiness checks.
+type Registry struct {
+	m         sync.Mutex
+	statuses  map[string]Status
+	liveness  map[string]*check
+	readiness map[string]*check
+}
+
+// DefaultRegistry is the registry used unless the context has one, see ContextWithRegistry.
+var DefaultRegistry = NewRegistry()
+
+type registryKey struct{}
+
+// NewRegistry returns the empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		statuses:  make(map[string]Status),
+		liveness:  make(map[string]*check),
+		readiness: make(map[string]*check),
+	}
+}
+
+// ContextWithRegistry returns a new context with the given registry.
+func ContextWithRegistry(ctx context.Context, registry *Registry) context.Context {
+	return context.WithValue(ctx, registryKey{}, registry)
+}
+
+// RegistryFromContext returns the registry from the given context, or the DefaultRegistry.
+func RegistryFromContext(ctx context.Context) *Registry {
+	if r, ok := ctx.Value(registryKey{}).(*Registry); ok {
+		return r
+	}
+	return DefaultRegistry
+}
+
+// ServiceName returns the service name of the route path, e.g. "/version.v1.VersionService/" is "version.v1.VersionService".
+func ServiceName(routePath string) string {
+	return strings.Trim(routePath, "/")
+}
+
+// SetStatus sets the service status.
+func (r *Registry) SetStatus(service string, status Status) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.statuses[service] = status
+}
+
+// SetServing sets the status of the server and of the services of the route paths, see ServiceName.
+func (r *Registry) SetServing(status Status, routePaths ...string) {
+	r.SetStatus(Server, status)
+	for _, routePath := range routePaths {
+		r.SetStatus(ServiceName(routePath), status)
+	}
+}
+
+// Status returns the service status, false if the service is not registered.
+func (r *Registry) Status(service string) (Status, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	status, ok := r.statuses[service]
+	return status, ok
+}
@@ -2,6 +2,7 @@ package insights
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -62,6 +63,20 @@ func RegistrerFromContext(ctx context.Context) prometheus.Registerer {
 	return prometheus.DefaultRegisterer
 }
 
+// RegisterCollector registers the collector, or returns the already registered collector of the same type,
+// e.g. registered by the previous server in the same registry. The returned collector is shared, so the collectors
+// of the instance state must report the state of every instance added to it, not only of the instance which created it.
+func RegisterCollector[C prometheus.Collector](registerer prometheus.Registerer, collector C) (C, error) {
+	err := registerer.Register(collector)
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return collector, err
+}
+
 // GathererFromContext returns the gatherer from the given context.
 func GathererFromContext(ctx context.Context) prometheus.Gatherer {
 	v := ctx.Value(registrerKey{})
@@ -0,0 +1,46 @@
+package insights_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/insights"
+)
+
+func TestRegisterCollectorReturnsRegisteredCollector(t *testing.T) {
+	t.Parallel()
+	// arrange
+	registry := prometheus.NewRegistry()
+	opts := prometheus.CounterOpts{Name: "test_total", Help: "Test counter."}
+	first, err := insights.RegisterCollector(registry, prometheus.NewCounter(opts))
+	require.NoError(t, err)
+
+	// act
+	second, err := insights.RegisterCollector(registry, prometheus.NewCounter(opts))
+	require.NoError(t, err)
+	second.Inc()
+
+	// assert
+	assert.Same(t, first, second)
+	assert.InDelta(t, 1.0, testutil.ToFloat64(first), 0)
+}
+
+func TestRegisterCollectorFailsOnConflict(t *testing.T) {
+	t.Parallel()
+	// arrange
+	registry := prometheus.NewRegistry()
+	_, err := insights.RegisterCollector(registry,
+		prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "Test counter."}))
+	require.NoError(t, err)
+
+	// act
+	_, err = insights.RegisterCollector(registry,
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_total", Help: "Test gauge."}))
+
+	// assert
+	require.Error(t, err)
+}
@@ -1,21 +1,37 @@
 package insights
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+type trustedTraceKey struct{}
+
 // NewOtelHandlerMiddleware returns an HTTP handler with OpenTelemetry instrumentation.
 // The handler parameter is the original HTTP handler to be instrumented.
 // The second parameter is unused.
 // It adds middleware to handle tracing and span name formatting.
 // The span name formatter uses the HTTP method and URL path.
+// Requests are treated as public endpoints (new trace linked to the incoming one),
+// unless the request context is marked with ContextWithTrustedTrace.
 func NewOtelHandlerMiddleware(handler http.Handler, operation string) http.Handler {
 	return otelhttp.NewHandler(handler, operation,
-		otelhttp.WithPublicEndpoint(),
+		otelhttp.WithPublicEndpointFn(isPublicEndpointRequest),
 		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
 			return fmt.Sprintf("%s %s %s", operation, r.Method, r.URL.Path)
 		}))
 }
+
+// ContextWithTrustedTrace returns a new context marking the request trace headers as trusted,
+// e.g. set by the NATS.io worker consumer span. The HTTP span continues the trace from the request headers.
+func ContextWithTrustedTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trustedTraceKey{}, true)
+}
+
+func isPublicEndpointRequest(r *http.Request) bool {
+	trusted, _ := r.Context().Value(trustedTraceKey{}).(bool)
+	return !trusted
+}
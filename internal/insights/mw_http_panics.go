@@ -0,0 +1,25 @@
+package insights
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/leonardinius/go-service-template/internal/insights/panicmetrics"
+)
+
+// NewPanicsMetricsHandlerMiddleware registers the panics_total metric and adds it to the request context,
+// so the recovered panics are counted, see panicmetrics.Record.
+// The metric is shared by the servers of the same registry, the panics of every server are counted.
+func NewPanicsMetricsHandlerMiddleware(next http.Handler, ctx context.Context) http.Handler {
+	// a conflicting panics_total metric of the registry is kept, the panics are not exported then
+	panics, _ := RegisterCollector(RegistrerFromContext(ctx), prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "panics_total",
+		Help: "Number of recovered panics.",
+	}, []string{"procedure"}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(panicmetrics.ContextWithCounter(r.Context(), panics)))
+	})
+}
@@ -0,0 +1,24 @@
+// Package panicmetrics counts the recovered panics by procedure in the panics_total metric.
+// The metric is passed with the request context, so the HTTP middleware and the Connect interceptors record panics
+// to the same metric.
+package panicmetrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type counterKey struct{}
+
+// ContextWithCounter returns a new context with the given panics_total metric.
+func ContextWithCounter(ctx context.Context, panics *prometheus.CounterVec) context.Context {
+	return context.WithValue(ctx, counterKey{}, panics)
+}
+
+// Record increments the panics_total metric of the procedure, if the context has the metric.
+func Record(ctx context.Context, procedure string) {
+	if panics, ok := ctx.Value(counterKey{}).(*prometheus.CounterVec); ok {
+		panics.WithLabelValues(procedure).Inc()
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"github.com/leonardinius/go-service-template/internal/insights"
 )
 
+// defaultLevel is the default logger level, it can be changed at runtime with SetLevel.
+var defaultLevel = new(slog.LevelVar)
+
 // InitDefaultLogger initializes a default logger with the default writer and log level.
 func InitDefaultLogger(w io.Writer, level slog.Level) *slog.Logger {
-	handler := NewJSONHandler(w, level)
+	defaultLevel.Set(level)
+	handler := NewJSONHandler(w, defaultLevel)
 	handler = insights.NewLogOtelMiddleware(handler)
 	logger := NewLogger(handler)
 	slog.SetLogLoggerLevel(level)
@@ -17,7 +21,18 @@ func InitDefaultLogger(w io.Writer, level slog.Level) *slog.Logger {
 	return logger
 }
 
-func NewJSONHandler(w io.Writer, level slog.Level) slog.Handler {
+// Level returns the default logger level.
+func Level() slog.Level {
+	return defaultLevel.Level()
+}
+
+// SetLevel changes the default logger level at runtime.
+func SetLevel(level slog.Level) {
+	defaultLevel.Set(level)
+	slog.SetLogLoggerLevel(level)
+}
+
+func NewJSONHandler(w io.Writer, level slog.Leveler) slog.Handler {
 	return slog.NewJSONHandler(w, &slog.HandlerOptions{
 		Level: level,
 	})
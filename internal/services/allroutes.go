@@ -3,15 +3,33 @@ package services
 import (
 	"net/http"
 
+	"connectrpc.com/grpcreflect"
+
 	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/health"
+	"github.com/leonardinius/go-service-template/internal/services/healthcheck"
 	"github.com/leonardinius/go-service-template/internal/services/version"
 )
 
-var AllRoutes = []apiserv.Route{
+var AllRoutes = withReflection(
 	newHandlerRoute(version.NewVersionServiceHandler),
-}
+	newHandlerRoute(healthcheck.NewHealthServiceHandler),
+)
 
 func newHandlerRoute(pathHandler func() (string, http.Handler)) apiserv.Route {
 	path, handler := pathHandler()
 	return apiserv.NewRoute(path, handler)
 }
+
+// withReflection adds the gRPC server reflection v1 and v1alpha routes, which describe the services of the routes.
+func withReflection(routes ...apiserv.Route) []apiserv.Route {
+	services := make([]string, 0, len(routes))
+	for _, route := range routes {
+		services = append(services, health.ServiceName(route.Path()))
+	}
+	reflector := grpcreflect.NewStaticReflector(services...)
+	return append(routes,
+		newHandlerRoute(func() (string, http.Handler) { return grpcreflect.NewHandlerV1(reflector) }),
+		newHandlerRoute(func() (string, http.Handler) { return grpcreflect.NewHandlerV1Alpha(reflector) }),
+	)
+}
@@ -0,0 +1,49 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+
+	"github.com/leonardinius/go-service-template/internal/health"
+	"github.com/leonardinius/go-service-template/internal/services/serviceotel"
+)
+
+// HealthServiceName is the gRPC health checking service name.
+const HealthServiceName = grpchealth.HealthV1ServiceName
+
+var errUnknownService = errors.New("unknown service")
+
+// servingStatus maps the registry statuses to the gRPC health statuses.
+var servingStatus = map[health.Status]grpchealth.Status{
+	health.StatusUnknown:    grpchealth.StatusUnknown,
+	health.StatusServing:    grpchealth.StatusServing,
+	health.StatusNotServing: grpchealth.StatusNotServing,
+}
+
+// NewHealthServiceHandler returns the grpc.health.v1.Health service handler, it reports the statuses of
+// the request context registry, see health.RegistryFromContext. The empty service name is the server status,
+// Check reports it as serving only while the registry readiness checks pass, see health.Registry.Readiness.
+// Watch is not implemented, see grpchealth.NewHandler.
+func NewHealthServiceHandler() (string, http.Handler) {
+	return grpchealth.NewHandler(registryChecker{},
+		connect.WithInterceptors(serviceotel.DefaultServicesInterceptors()...))
+}
+
+// registryChecker is the grpchealth.Checker of the request context registry.
+type registryChecker struct{}
+
+func (registryChecker) Check(ctx context.Context, req *grpchealth.CheckRequest) (*grpchealth.CheckResponse, error) {
+	registry := health.RegistryFromContext(ctx)
+	status, ok := registry.Status(req.Service)
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errUnknownService)
+	}
+	if req.Service == health.Server && status == health.StatusServing && !registry.Readiness(ctx).OK() {
+		status = health.StatusNotServing
+	}
+	return &grpchealth.CheckResponse{Status: servingStatus[status]}, nil
+}
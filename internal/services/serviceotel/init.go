@@ -3,6 +3,10 @@ package serviceotel
 import (
 	"connectrpc.com/connect"
 	"connectrpc.com/otelconnect"
+
+	"github.com/leonardinius/go-service-template/internal/apierrors"
+	"github.com/leonardinius/go-service-template/internal/services/servicerecover"
+	"github.com/leonardinius/go-service-template/internal/services/servicevalidate"
 )
 
 func DefaultServicesInterceptors() []connect.Interceptor {
@@ -10,5 +14,10 @@ func DefaultServicesInterceptors() []connect.Interceptor {
 	if interceptor, err := otelconnect.NewInterceptor(); err == nil {
 		interceptors = append(interceptors, interceptor)
 	}
+	// recover, render and validate after the otel interceptor, so the traces have the error codes
+	interceptors = append(interceptors,
+		servicerecover.NewInterceptor(),
+		apierrors.NewInterceptor(),
+		servicevalidate.NewInterceptor())
 	return interceptors
 }
@@ -0,0 +1,66 @@
+package servicerecover
+
+import (
+	"context"
+	"log/slog"
+
+	"connectrpc.com/connect"
+
+	"github.com/leonardinius/go-service-template/internal/apierrors"
+	"github.com/leonardinius/go-service-template/internal/insights/panicmetrics"
+)
+
+type interceptor struct{}
+
+var _ connect.Interceptor = (*interceptor)(nil)
+
+// NewInterceptor returns the interceptor recovering the handler panics. The panic is logged and counted,
+// see panicmetrics.Record, and the handler fails with the Internal code and the trace ID in the error details.
+// gRPC clients get the grpc-status trailers, Connect clients get the Connect error.
+func NewInterceptor() connect.Interceptor {
+	return &interceptor{}
+}
+
+// WrapUnary implements connect.Interceptor.
+func (*interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (resp connect.AnyResponse, err error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				resp, err = nil, recovered(ctx, req.Spec().Procedure, p)
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (*interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (*interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ctx, conn.Spec().Procedure, p)
+			}
+		}()
+		return next(ctx, conn)
+	}
+}
+
+// recovered logs and counts the recovered panic, and returns it as the Connect internal error.
+func recovered(ctx context.Context, procedure string, p any) error {
+	panicErr := apierrors.NewPanicError(p)
+	slog.LogAttrs(ctx, slog.LevelError, "recovered from panic",
+		slog.String("procedure", procedure),
+		slog.String("error", panicErr.Error()),
+		slog.String("stack", panicErr.Stack()),
+	)
+	panicmetrics.Record(ctx, procedure)
+	return panicErr.InternalError(ctx).ConnectError(ctx)
+}
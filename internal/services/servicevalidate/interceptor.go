@@ -0,0 +1,131 @@
+package servicevalidate
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+
+	"github.com/leonardinius/go-service-template/internal/apierrors"
+)
+
+// validatorAll is implemented by the protoc-gen-validate generated messages.
+type validatorAll interface {
+	ValidateAll() error
+}
+
+// validator is implemented by the protoc-gen-validate generated messages.
+type validator interface {
+	Validate() error
+}
+
+// multiError is the protoc-gen-validate error of all the message violations, see validatorAll.
+type multiError interface {
+	AllErrors() []error
+}
+
+// fieldError is the protoc-gen-validate error of a single field violation.
+// The cause of an embedded message violation is the embedded message validation error.
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+type interceptor struct{}
+
+var _ connect.Interceptor = (*interceptor)(nil)
+
+// NewInterceptor returns the interceptor validating the handler request messages with the protoc-gen-validate rules.
+// Invalid requests fail with the InvalidArgument code, the field violations are set as the nested errors
+// of the shared.v1.Error detail, keyed by the field path, e.g. "Version.ServiceName".
+func NewInterceptor() connect.Interceptor {
+	return &interceptor{}
+}
+
+// WrapUnary implements connect.Interceptor.
+func (*interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		if err := validate(req.Any()); err != nil {
+			return nil, err.ConnectError(ctx)
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (*interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor. Every received message is validated.
+func (*interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return next(ctx, &validatingHandlerConn{StreamingHandlerConn: conn, ctx: ctx})
+	}
+}
+
+type validatingHandlerConn struct {
+	connect.StreamingHandlerConn
+	ctx context.Context //nolint:containedctx // the handler context, Receive has no context
+}
+
+func (c *validatingHandlerConn) Receive(msg any) error {
+	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+		return err
+	}
+	if err := validate(msg); err != nil {
+		return err.ConnectError(c.ctx)
+	}
+	return nil
+}
+
+// validate returns the InvalidArgument error if the message violates its validation rules.
+// Messages without validation rules are valid.
+func validate(msg any) *apierrors.Error {
+	var err error
+	switch m := msg.(type) {
+	case validatorAll:
+		err = m.ValidateAll()
+	case validator:
+		err = m.Validate()
+	}
+	if err == nil {
+		return nil
+	}
+
+	violations := make(map[string]string)
+	collectViolations(violations, "", err)
+	return apierrors.New(connect.CodeInvalidArgument, "invalid request", apierrors.WithFields(violations))
+}
+
+// collectViolations adds the violation reasons keyed by the field path, embedded messages violations are flattened.
+func collectViolations(violations map[string]string, prefix string, err error) {
+	var multi multiError
+	if errors.As(err, &multi) {
+		for _, err := range multi.AllErrors() {
+			collectViolations(violations, prefix, err)
+		}
+		return
+	}
+
+	var field fieldError
+	if !errors.As(err, &field) {
+		violations[prefix] = err.Error()
+		return
+	}
+	path := field.Field()
+	if prefix != "" {
+		path = prefix + "." + path
+	}
+	var nestedMulti multiError
+	var nestedField fieldError
+	if cause := field.Cause(); cause != nil && (errors.As(cause, &nestedMulti) || errors.As(cause, &nestedField)) {
+		collectViolations(violations, path, cause)
+		return
+	}
+	violations[path] = field.Reason()
+}
@@ -0,0 +1,81 @@
+package servicevalidate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/services/servicevalidate"
+
+	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
+)
+
+// testValidationError mimics the protoc-gen-validate field violation error.
+type testValidationError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e testValidationError) Field() string  { return e.field }
+func (e testValidationError) Reason() string { return e.reason }
+func (e testValidationError) Cause() error   { return e.cause }
+func (e testValidationError) Error() string  { return e.field + ": " + e.reason }
+
+// testMultiError mimics the protoc-gen-validate error of all the message violations.
+type testMultiError []error
+
+func (m testMultiError) Error() string      { return errors.Join(m...).Error() }
+func (m testMultiError) AllErrors() []error { return m }
+
+type testMessage struct {
+	err error
+}
+
+func (m *testMessage) ValidateAll() error { return m.err }
+
+func TestInterceptorSmokeTest(t *testing.T) {
+	t.Parallel()
+	// arrange: a handler which records the call
+	called := false
+	next := func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		called = true
+		return connect.NewResponse(&sharedv1.Error{}), nil
+	}
+	unary := servicevalidate.NewInterceptor().WrapUnary(next)
+
+	// act: a valid request
+	_, err := unary(t.Context(), connect.NewRequest(&testMessage{}))
+
+	// assert: the handler is called
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	// act: an invalid request, with an embedded message violation
+	called = false
+	_, err = unary(t.Context(), connect.NewRequest(&testMessage{err: testMultiError{
+		testValidationError{field: "Name", reason: "value length must be at least 1 runes"},
+		testValidationError{field: "Version", reason: "embedded message failed validation", cause: testMultiError{
+			testValidationError{field: "Commit", reason: "value must be a valid hex string"},
+		}},
+	}}))
+
+	// assert: InvalidArgument with the per-field violations, the handler is not called
+	assert.False(t, called)
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+	require.Len(t, connectErr.Details(), 1)
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	sharedErr, ok := detail.(*sharedv1.Error)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"Name":           "value length must be at least 1 runes",
+		"Version.Commit": "value must be a valid hex string",
+	}, sharedErr.GetNestedErrors())
+}
@@ -3,12 +3,14 @@ package testnats
 import (
 	"context"
 	"net"
+	"net/http"
 	"strconv"
-	"strings"
 	"testing"
 
 	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/require"
+
+	"github.com/leonardinius/go-service-template/internal/apiworker"
 )
 
 func MustConnect(t *testing.T, ctx context.Context, port int) *nats.Conn {
@@ -24,8 +26,15 @@ func MustConnect(t *testing.T, ctx context.Context, port int) *nats.Conn {
 func Request(t *testing.T, ctx context.Context, nc *nats.Conn, path string, payload []byte) (*nats.Msg, error) {
 	t.Helper()
 
-	subj := pathToSubject(path)
-	return nc.RequestWithContext(ctx, subj, payload)
+	subj := PathToSubject(path)
+	return canonicalReply(nc.RequestWithContext(ctx, subj, payload))
+}
+
+// RequestMsg sends the request message and returns the reply, see Request.
+func RequestMsg(t *testing.T, ctx context.Context, nc *nats.Conn, msg *nats.Msg) (*nats.Msg, error) {
+	t.Helper()
+
+	return canonicalReply(nc.RequestMsgWithContext(ctx, msg))
 }
 
 func MustRequest(t *testing.T, ctx context.Context, nc *nats.Conn, path string, payload []byte) *nats.Msg {
@@ -36,8 +45,22 @@ func MustRequest(t *testing.T, ctx context.Context, nc *nats.Conn, path string,
 	return msg
 }
 
-func pathToSubject(path string) string {
-	subj := strings.TrimLeft(path, "/")
-	subj = strings.ReplaceAll(subj, "/", ".")
-	return subj
+// canonicalReply canonicalizes the reply header keys, like the HTTP client of the worker does.
+// The worker replies canonical keys, but nats-server rewrites the W3C "traceparent" key in lower case.
+func canonicalReply(reply *nats.Msg, err error) (*nats.Msg, error) {
+	if err != nil || reply.Header == nil {
+		return reply, err
+	}
+	header := make(nats.Header, len(reply.Header))
+	for k, v := range reply.Header {
+		k = http.CanonicalHeaderKey(k)
+		header[k] = append(header[k], v...)
+	}
+	reply.Header = header
+	return reply, nil
+}
+
+// PathToSubject converts the HTTP handler path to the NATS.io request subject.
+func PathToSubject(path string) string {
+	return apiworker.Subjects{}.Subject(path)
 }
@@ -19,10 +19,24 @@ const (
 func MustRunNatsServer(t *testing.T, ctx context.Context, port int) *server.Server {
 	t.Helper()
 
-	opts := &server.Options{
-		Port: port,
-		Host: Host,
-	}
+	return mustRunNatsServer(t, ctx, port, &server.Options{})
+}
+
+// MustRunJetStreamNatsServer starts NATS server with JetStream enabled, storage is cleaned up after the test.
+func MustRunJetStreamNatsServer(t *testing.T, ctx context.Context, port int) *server.Server {
+	t.Helper()
+
+	return mustRunNatsServer(t, ctx, port, &server.Options{
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+}
+
+func mustRunNatsServer(t *testing.T, ctx context.Context, port int, opts *server.Options) *server.Server {
+	t.Helper()
+
+	opts.Port = port
+	opts.Host = Host
 	ns, err := server.NewServer(opts)
 	require.NoErrorf(t, err, "Failed to create NATS server: %v", err)
 	go ns.Start()
@@ -2,30 +2,56 @@ package apiworkere2e_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nats.go/micro"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/leonardinius/go-service-template/app/cmd"
 	"github.com/leonardinius/go-service-template/internal/apigen/version/v1/versionv1connect"
+	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/apiworker"
+	"github.com/leonardinius/go-service-template/internal/health"
 	"github.com/leonardinius/go-service-template/internal/insights"
+	"github.com/leonardinius/go-service-template/internal/services"
+	"github.com/leonardinius/go-service-template/internal/services/healthcheck"
+	"github.com/leonardinius/go-service-template/internal/services/serviceotel"
 	"github.com/leonardinius/go-service-template/internal/services/version"
 	"github.com/leonardinius/go-service-template/teste2e/internal/testbind"
 	"github.com/leonardinius/go-service-template/teste2e/internal/testhttp"
 	"github.com/leonardinius/go-service-template/teste2e/internal/testnats"
 
+	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
 	versionv1 "github.com/leonardinius/go-service-template/internal/apigen/version/v1"
+	testv1 "github.com/leonardinius/go-service-template/teste2e/internal/testapi/test/v1"
 )
 
 const (
 	Host = testnats.Host
+
+	healthCheckProcedure          = "/" + healthcheck.HealthServiceName + "/Check"
+	serverReflectionInfoProcedure = "/" + grpcreflect.ReflectV1ServiceName + "/ServerReflectionInfo"
 )
 
 func TestVersionRequestReplyNATS(t *testing.T) {
@@ -42,6 +68,101 @@ func TestVersionRequestReplyNATS(t *testing.T) {
 	})
 }
 
+func TestVersionConnectClientNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		nc := testnats.MustConnect(t, ctx, port)
+		client := versionv1connect.NewVersionServiceClient(apiworker.NewHTTPClient(nc), "nats://")
+
+		resp, err := client.GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+
+		require.NoError(t, err)
+		assert.Equal(t, version.FullVersion, resp.Msg.GetVersion().GetFullVersion())
+		assert.Equal(t, "application/proto", resp.Header().Get("Content-Type"))
+		assert.Len(t, resp.Header().Get("X-Trace-Id"), 32)
+	})
+}
+
+func TestSubjectPrefixAndRouteSubjectNATS(t *testing.T) {
+	t.Parallel()
+	natsPort := testbind.DynamicPort()
+	metricsPort := testbind.DynamicPort()
+	ctx, cancel := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer cancel()
+	ns := testnats.MustRunNatsServer(t, ctx, natsPort)
+	defer ns.Shutdown()
+
+	startWorker(ctx, t, natsPort, metricsPort,
+		"--subject-prefix=prod.svc.", "--route-subject="+versionv1connect.VersionServiceName+"=version")
+	nc := testnats.MustConnect(t, ctx, natsPort)
+	subjects := apiworker.Subjects{
+		Prefix: "prod.svc.",
+		Routes: map[string]string{"/" + versionv1connect.VersionServiceName + "/": "version"},
+	}
+	require.Equal(t, "prod.svc.version.GetVersion", subjects.Subject(versionv1connect.VersionServiceGetVersionProcedure))
+
+	client := versionv1connect.NewVersionServiceClient(apiworker.NewHTTPClient(nc, apiworker.WithClientSubjects(subjects)), "nats://")
+	resp, err := client.GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+	require.NoError(t, err)
+	assert.Equal(t, version.FullVersion, resp.Msg.GetVersion().GetFullVersion())
+
+	// the unprefixed subject is not served
+	_, err = testnats.Request(t, ctx, nc, versionv1connect.VersionServiceGetVersionProcedure, []byte("{}"))
+	require.ErrorIs(t, err, nats.ErrNoResponders)
+}
+
+func TestVersionGrpcClientNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		nc := testnats.MustConnect(t, ctx, port)
+		client := versionv1connect.NewVersionServiceClient(apiworker.NewHTTPClient(nc), "nats://",
+			connect.WithGRPC(),
+		)
+
+		resp, err := client.GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+
+		require.NoError(t, err)
+		assert.Equal(t, version.FullVersion, resp.Msg.GetVersion().GetFullVersion())
+		assert.Equal(t, "0", resp.Trailer().Get("Grpc-Status"))
+	})
+}
+
+func TestVersionGrpcWebClientNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		nc := testnats.MustConnect(t, ctx, port)
+		client := versionv1connect.NewVersionServiceClient(apiworker.NewHTTPClient(nc), "nats://",
+			connect.WithGRPCWeb(),
+		)
+
+		resp, err := client.GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+
+		require.NoError(t, err)
+		assert.Equal(t, version.FullVersion, resp.Msg.GetVersion().GetFullVersion())
+	})
+}
+
+func TestVersionRequestReplyProtoNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		nc := testnats.MustConnect(t, ctx, port)
+		payload, err := proto.Marshal(&versionv1.GetVersionRequest{})
+		require.NoError(t, err)
+		msg := nats.NewMsg(testnats.PathToSubject(versionv1connect.VersionServiceGetVersionProcedure))
+		msg.Header.Set("Content-Type", "application/proto")
+		msg.Data = payload
+
+		reply, err := nc.RequestMsgWithContext(ctx, msg)
+
+		require.NoError(t, err)
+		assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+		assert.Equal(t, []string{"application/proto"}, reply.Header.Values("Content-Type"))
+		resp := versionv1.GetVersionResponse{}
+		require.NoError(t, proto.Unmarshal(reply.Data, &resp))
+		assert.Equal(t, version.FullVersion, resp.GetVersion().GetFullVersion())
+	})
+}
+
 func TestVersionRequest404ReplyErrorNATS(t *testing.T) {
 	t.Parallel()
 	runTest(t, func(ctx context.Context, port, _ int) {
@@ -71,6 +192,93 @@ func TestOtelHasXTraceHeadersNATSReplyMessage(t *testing.T) {
 	})
 }
 
+func TestMicroServiceDiscoveryNATS(t *testing.T) {
+	t.Parallel()
+	natsPort := testbind.DynamicPort()
+	ctx, cancel := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer cancel()
+	ns := testnats.MustRunNatsServer(t, ctx, natsPort)
+	defer ns.Shutdown()
+
+	startWorker(ctx, t, natsPort, testbind.DynamicPort(), "--micro", "--max-inflight=4")
+	nc := testnats.MustConnect(t, ctx, natsPort)
+	_ = testnats.MustRequest(t, ctx, nc,
+		versionv1connect.VersionServiceGetVersionProcedure,
+		[]byte("{}"))
+
+	ping := micro.Ping{}
+	reply, err := nc.RequestWithContext(ctx, "$SRV.PING", nil)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(reply.Data, &ping))
+	assert.Equal(t, version.FullVersion, ping.Metadata["full_version"])
+
+	// every route is a single endpoint regardless of the in-flight limit
+	info := micro.Info{}
+	reply, err = nc.RequestWithContext(ctx, "$SRV.INFO."+ping.Name, nil)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(reply.Data, &info))
+	require.Len(t, info.Endpoints, len(services.AllRoutes))
+	assert.Equal(t, "version.v1.VersionService.>", info.Endpoints[0].Subject)
+
+	// the request is counted once the handler returns, which may happen after the reply
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		stats := micro.Stats{}
+		reply, err := nc.RequestWithContext(ctx, "$SRV.STATS."+ping.Name+"."+ping.ID, nil)
+		require.NoError(c, err)
+		require.NoError(c, json.Unmarshal(reply.Data, &stats))
+		require.Len(c, stats.Endpoints, len(services.AllRoutes))
+		assert.Equal(c, 1, stats.Endpoints[0].NumRequests)
+		assert.Equal(c, 0, stats.Endpoints[0].NumErrors)
+		assert.Positive(c, stats.Endpoints[0].ProcessingTime)
+	}, time.Second, 10*time.Millisecond)
+
+	// the 5xx replies are counted as the endpoint errors
+	const procedure = "/test.v1.TestService/Fail"
+	handler := connect.NewUnaryHandler(procedure, func(
+		context.Context,
+		*connect.Request[emptypb.Empty],
+	) (*connect.Response[emptypb.Empty], error) {
+		return nil, connect.NewError(connect.CodeInternal, errors.New("failed"))
+	})
+	startCustomWorker(ctx, t, natsPort, apiserv.NewRoute("/test.v1.TestService/", handler),
+		apiworker.WithMicroService("test", "1.0.0"))
+	reply, err = testnats.Request(t, ctx, nc, procedure, []byte("{}"))
+	require.NoError(t, err)
+	assert.Equal(t, "500", reply.Header.Get(micro.ErrorCodeHeader))
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		stats := micro.Stats{}
+		reply, err := nc.RequestWithContext(ctx, "$SRV.STATS.test", nil)
+		require.NoError(c, err)
+		require.NoError(c, json.Unmarshal(reply.Data, &stats))
+		require.Len(c, stats.Endpoints, 1)
+		assert.Equal(c, 1, stats.Endpoints[0].NumRequests)
+		assert.Equal(c, 1, stats.Endpoints[0].NumErrors)
+		assert.Contains(c, stats.Endpoints[0].LastError, "500")
+		assert.Positive(c, stats.Endpoints[0].ProcessingTime)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOtelContinuesCallerTraceNATSReplyMessage(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const (
+			traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+			traceParent = "00-" + traceID + "-00f067aa0ba902b7-01"
+		)
+		nc := testnats.MustConnect(t, ctx, port)
+		msg := nats.NewMsg(testnats.PathToSubject(versionv1connect.VersionServiceGetVersionProcedure))
+		msg.Header.Set("traceparent", traceParent)
+		msg.Data = []byte("{}")
+
+		reply, err := testnats.RequestMsg(t, ctx, nc, msg)
+
+		require.NoError(t, err)
+		assert.Equal(t, traceID, reply.Header.Get("X-Trace-Id"))
+		assert.Contains(t, reply.Header.Get("Traceparent"), traceID)
+		assert.NotEqual(t, traceParent, reply.Header.Get("Traceparent"))
+	})
+}
+
 func TestServeNATSMetrics(t *testing.T) {
 	t.Parallel()
 	runTest(t, func(ctx context.Context, port, metricsPort int) {
@@ -86,40 +294,853 @@ func TestServeNATSMetrics(t *testing.T) {
 		assert.Contains(t, contents, "# TYPE go_info gauge")
 		assert.Contains(t, contents, "go_info{")
 		assert.Contains(t, contents, versionv1connect.VersionServiceGetVersionProcedure)
+		// the route subscriptions are reported by the default worker
+		assert.Contains(t, contents, `nats_worker_pending_messages{subject="version.v1.VersionService.>"} 0`)
 		_ = resp.Body.Close()
 	})
 }
 
-func runTest(t *testing.T, test func(ctx context.Context, natsPort, metricsPort int)) {
-	t.Helper()
+func TestHealthProbesNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, _, metricsPort int) {
+		for _, path := range []string{"/healthz", "/readyz"} {
+			resp := testhttp.MustGET(ctx, t, endpointURL("http://localhost:{{port}}", metricsPort, path))
+			assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"), path)
+			health := struct {
+				Status string `json:"status"`
+				Checks map[string]struct {
+					Status  string `json:"status"`
+					Message string `json:"message"`
+				} `json:"checks"`
+			}{}
+			testhttp.MustReadFullyJSON(t, resp, &health)
+			assert.Equal(t, "ok", health.Status, path)
+			assert.Equal(t, "ok", health.Checks["nats"].Status, path)
+			_ = resp.Body.Close()
+		}
+	})
+}
+
+func TestStuckDispatchFailsLivenessMicroNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const procedure = "/test.v1.TestService/Block"
+		release := make(chan struct{})
+		handler := connect.NewUnaryHandler(procedure, func(
+			context.Context,
+			*connect.Request[emptypb.Empty],
+		) (*connect.Response[emptypb.Empty], error) {
+			// ignores the context on purpose
+			<-release
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		})
+		metricsPort := testbind.DynamicPort()
+		startCustomWorkerWithMetrics(ctx, t, port, metricsPort, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithMicroService("test", "1.0.0"),
+			apiworker.WithDispatchStuckTimeout(200*time.Millisecond))
+		liveness := func() (int, string) {
+			resp := testhttp.MustGET(ctx, t, endpointURL("http://localhost:{{port}}", metricsPort, "/healthz"))
+			defer func() { _ = resp.Body.Close() }()
+			return resp.StatusCode, testhttp.MustReadFullyString(t, resp)
+		}
+
+		// the endpoint handles one request at a time, the blocked request holds the endpoint
+		nc := testnats.MustConnect(t, ctx, port)
+		for range 2 {
+			require.NoError(t, nc.Publish(testnats.PathToSubject(procedure), []byte("{}")))
+		}
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			status, body := liveness()
+			assert.Equal(c, http.StatusServiceUnavailable, status)
+			assert.Contains(c, body, "request in flight, no requests dispatched")
+		}, 5*time.Second, 50*time.Millisecond)
+
+		// the released requests are dispatched
+		close(release)
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			status, _ := liveness()
+			assert.Equal(c, http.StatusOK, status)
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
+
+func TestQueueGroupHandlesEachRequestOnceNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		// two more replicas in the same (default) queue group
+		startWorker(ctx, t, port, testbind.DynamicPort())
+		startWorker(ctx, t, port, testbind.DynamicPort())
+
+		nc := testnats.MustConnect(t, ctx, port)
+		inbox := nats.NewInbox()
+		replies, err := nc.SubscribeSync(inbox)
+		require.NoError(t, err)
+
+		const requests = 30
+		subject := testnats.PathToSubject(versionv1connect.VersionServiceGetVersionProcedure)
+		for range requests {
+			require.NoError(t, nc.PublishRequest(subject, inbox, []byte("{}")))
+		}
+		require.NoError(t, nc.Flush())
+
+		received := 0
+		for {
+			_, err := replies.NextMsg(500 * time.Millisecond)
+			if errors.Is(err, nats.ErrTimeout) {
+				break
+			}
+			require.NoError(t, err)
+			received++
+		}
+		assert.Equal(t, requests, received, "Expected each request to be handled exactly once")
+	})
+}
+
+func TestJetStreamRequestSentWhileWorkerIsDownNATS(t *testing.T) {
+	t.Parallel()
 
 	natsServerPort := testbind.DynamicPort()
-	metricsPort := testbind.DynamicPort()
+	ctx, stopMain := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer stopMain()
 
-	ctx := context.WithoutCancel(rootTestCtx)
-	ctx = insights.ContextWithRegistry(ctx, insights.NewMetricsRegistry())
-	ctx, stopMain := context.WithCancel(ctx)
+	ns := testnats.MustRunJetStreamNatsServer(t, ctx, natsServerPort)
+	defer ns.Shutdown()
+
+	// first worker run creates the stream and durable consumers
+	firstCtx, stopFirst := context.WithCancel(ctx)
+	firstMetricsPort := testbind.DynamicPort()
+	firstErrCh := startWorker(firstCtx, t, natsServerPort, firstMetricsPort, "--jetstream")
+	stopFirst()
+	require.NoError(t, <-firstErrCh)
+
+	// the request is published while no worker is running
+	nc := testnats.MustConnect(t, ctx, natsServerPort)
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	inbox := nats.NewInbox()
+	replies, err := nc.SubscribeSync(inbox)
+	require.NoError(t, err)
+	_, err = js.PublishMsg(ctx, &nats.Msg{
+		Subject: testnats.PathToSubject(versionv1connect.VersionServiceGetVersionProcedure),
+		Data:    []byte("{}"),
+		Header:  nats.Header{apiworker.ReplyToHeader: []string{inbox}},
+	})
+	require.NoError(t, err)
+
+	startWorker(ctx, t, natsServerPort, testbind.DynamicPort(), "--jetstream")
+	reply, err := replies.NextMsg(testbind.PortPollTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+	resp := versionv1.GetVersionResponse{}
+	require.NoError(t, protojson.Unmarshal(reply.Data, &resp))
+	assert.Equal(t, version.FullVersion, resp.GetVersion().GetFullVersion())
+}
+
+func TestJetStreamRequestLongerThanAckWaitNATS(t *testing.T) {
+	t.Parallel()
+	natsServerPort := testbind.DynamicPort()
+	ctx, stopMain := context.WithCancel(context.WithoutCancel(rootTestCtx))
 	defer stopMain()
+	ns := testnats.MustRunJetStreamNatsServer(t, ctx, natsServerPort)
+	defer ns.Shutdown()
 
-	ns := testnats.MustRunNatsServer(t, ctx, natsServerPort)
+	const procedure = "/test.v1.TestService/Sleep"
+	var calls atomic.Int32
+	handler := connect.NewUnaryHandler(procedure, func(
+		_ context.Context,
+		req *connect.Request[durationpb.Duration],
+	) (*connect.Response[emptypb.Empty], error) {
+		calls.Add(1)
+		time.Sleep(req.Msg.AsDuration())
+		return connect.NewResponse(&emptypb.Empty{}), nil
+	})
+	startCustomWorker(ctx, t, natsServerPort, apiserv.NewRoute("/test.v1.TestService/", handler),
+		apiworker.WithJetStream("test", "test"),
+		apiworker.WithAckWait(300*time.Millisecond),
+		apiworker.WithMaxInFlight(2))
+
+	nc := testnats.MustConnect(t, ctx, natsServerPort)
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	inbox := nats.NewInbox()
+	replies, err := nc.SubscribeSync(inbox)
+	require.NoError(t, err)
+	_, err = js.PublishMsg(ctx, &nats.Msg{
+		Subject: testnats.PathToSubject(procedure),
+		Data:    []byte(`"1s"`),
+		Header:  nats.Header{apiworker.ReplyToHeader: []string{inbox}},
+	})
+	require.NoError(t, err)
+
+	reply, err := replies.NextMsg(testbind.PortPollTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+	assert.Equal(t, int32(1), calls.Load(), "the request in progress is redelivered")
+}
+
+func TestJetStreamCanceledRequestRedeliveredNATS(t *testing.T) {
+	t.Parallel()
+	natsServerPort := testbind.DynamicPort()
+	ctx, stopMain := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer stopMain()
+	ns := testnats.MustRunJetStreamNatsServer(t, ctx, natsServerPort)
 	defer ns.Shutdown()
 
-	address := "nats://" + net.JoinHostPort(Host, strconv.Itoa(natsServerPort))
-	metricsAddress := net.JoinHostPort(Host, strconv.Itoa(metricsPort))
-	errCh := make(chan error, 1)
+	const procedure = "/test.v1.TestService/Ping"
+	var calls atomic.Int32
+	handler := connect.NewUnaryHandler(procedure, func(
+		_ context.Context,
+		_ *connect.Request[emptypb.Empty],
+	) (*connect.Response[emptypb.Empty], error) {
+		if calls.Add(1) == 1 {
+			return nil, connect.NewError(connect.CodeCanceled, context.Canceled)
+		}
+		return connect.NewResponse(&emptypb.Empty{}), nil
+	})
+	startCustomWorker(ctx, t, natsServerPort, apiserv.NewRoute("/test.v1.TestService/", handler),
+		apiworker.WithJetStream("test", "test"),
+		apiworker.WithNakDelay(50*time.Millisecond))
+
+	nc := testnats.MustConnect(t, ctx, natsServerPort)
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	inbox := nats.NewInbox()
+	replies, err := nc.SubscribeSync(inbox)
+	require.NoError(t, err)
+	_, err = js.PublishMsg(ctx, &nats.Msg{
+		Subject: testnats.PathToSubject(procedure),
+		Data:    []byte(`{}`),
+		Header:  nats.Header{apiworker.ReplyToHeader: []string{inbox}},
+	})
+	require.NoError(t, err)
+
+	reply, err := replies.NextMsg(testbind.PortPollTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+	assert.Equal(t, int32(2), calls.Load(), "the canceled request is not redelivered")
+}
+
+func TestValidateGeneratedRulesHTTPAndNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const procedure = "/test.v1.TestService/Echo"
+		handler := connect.NewUnaryHandler(procedure, func(
+			context.Context,
+			*connect.Request[testv1.EchoRequest],
+		) (*connect.Response[emptypb.Empty], error) {
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		}, connect.WithInterceptors(serviceotel.DefaultServicesInterceptors()...))
+		route := apiserv.NewRoute("/test.v1.TestService/", handler)
+		httpPort := testbind.DynamicPort()
+		srv, err := apiserv.NewDefaultServer(ctx, net.JoinHostPort(Host, strconv.Itoa(httpPort)), []apiserv.Route{route})
+		require.NoError(t, err)
+		go func() {
+			_ = apiserv.ListenAndServe(ctx, srv)
+		}()
+		testbind.MustWaitForPortListenUp(ctx, t, httpPort)
+		startCustomWorker(ctx, t, port, route)
+		nc := testnats.MustConnect(t, ctx, port)
+		transports := map[string]struct {
+			client connect.HTTPClient
+			url    string
+		}{
+			"http": {&http.Client{}, endpointURL("http://localhost:{{port}}", httpPort, procedure)},
+			"nats": {apiworker.NewHTTPClient(nc), "nats://" + procedure},
+		}
+
+		for name, transport := range transports {
+			for _, option := range []connect.ClientOption{connect.WithProtoJSON(), connect.WithGRPC()} {
+				client := connect.NewClient[testv1.EchoRequest, emptypb.Empty](transport.client, transport.url, option)
+
+				_, err := client.CallUnary(ctx, connect.NewRequest(&testv1.EchoRequest{Address: &testv1.Address{}}))
+
+				var connectErr *connect.Error
+				require.ErrorAs(t, err, &connectErr, name)
+				assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code(), name)
+				assert.Equal(t, "invalid request", connectErr.Message(), name)
+				require.Len(t, connectErr.Details(), 1, name)
+				detail, err := connectErr.Details()[0].Value()
+				require.NoError(t, err, name)
+				sharedErr, ok := detail.(*sharedv1.Error)
+				require.True(t, ok, name)
+				assert.Equal(t, map[string]string{
+					"Name":         "value length must be at least 1 runes",
+					"Address.City": "value length must be at least 1 runes",
+				}, sharedErr.GetNestedErrors(), name)
+			}
+		}
+	})
+}
+
+func TestHealthCheckGrpcClientNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		nc := testnats.MustConnect(t, ctx, port)
+		client := connect.NewClient[testv1.HealthCheckRequest, testv1.HealthCheckResponse](
+			apiworker.NewHTTPClient(nc), "nats://"+healthCheckProcedure, connect.WithGRPC())
+
+		for _, service := range []string{health.Server, "version.v1.VersionService"} {
+			resp, err := client.CallUnary(ctx, connect.NewRequest(&testv1.HealthCheckRequest{Service: service}))
+
+			require.NoError(t, err, service)
+			assert.Equal(t, testv1.HealthCheckResponse_SERVING_STATUS_SERVING, resp.Msg.GetStatus(), service)
+		}
+	})
+}
+
+func TestServerReflectionGrpcClientNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		nc := testnats.MustConnect(t, ctx, port)
+		client := connect.NewClient[testv1.ServerReflectionRequest, testv1.ServerReflectionResponse](
+			apiworker.NewHTTPClient(nc), "nats://"+serverReflectionInfoProcedure, connect.WithGRPC())
+
+		// the bidi stream is half-duplex over NATS.io, the request is sent once the request stream is closed
+		stream := client.CallBidiStream(ctx)
+		require.NoError(t, stream.Send(&testv1.ServerReflectionRequest{ListServices: proto.String("")}))
+		require.NoError(t, stream.CloseRequest())
+		resp, err := stream.Receive()
+		require.NoError(t, err)
+		require.NoError(t, stream.CloseResponse())
+
+		var services []string
+		for _, service := range resp.GetListServicesResponse().GetService() {
+			services = append(services, service.GetName())
+		}
+		assert.Contains(t, services, "version.v1.VersionService")
+		assert.Contains(t, services, healthcheck.HealthServiceName)
+	})
+}
+
+func TestServerStreamingConnectClientNATS(t *testing.T) {
+	t.Parallel()
+	for _, protocol := range []connect.ClientOption{connect.WithProtoJSON(), connect.WithGRPC(), connect.WithGRPCWeb()} {
+		runTest(t, func(ctx context.Context, port, _ int) {
+			const procedure = "/test.v1.TestService/Count"
+			handler := connect.NewServerStreamHandler(procedure, func(
+				_ context.Context,
+				req *connect.Request[wrapperspb.Int32Value],
+				stream *connect.ServerStream[wrapperspb.Int32Value],
+			) error {
+				for i := range req.Msg.GetValue() {
+					if err := stream.Send(wrapperspb.Int32(i)); err != nil {
+						return err
+					}
+				}
+				stream.ResponseTrailer().Set("X-Count", strconv.Itoa(int(req.Msg.GetValue())))
+				return nil
+			})
+			startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler))
+
+			nc := testnats.MustConnect(t, ctx, port)
+			client := connect.NewClient[wrapperspb.Int32Value, wrapperspb.Int32Value](
+				apiworker.NewHTTPClient(nc), "nats://"+procedure, protocol)
+			stream, err := client.CallServerStream(ctx, connect.NewRequest(wrapperspb.Int32(5)))
+			require.NoError(t, err)
+
+			var received []int32
+			for stream.Receive() {
+				received = append(received, stream.Msg().GetValue())
+			}
+			require.NoError(t, stream.Err())
+			require.NoError(t, stream.Close())
+			assert.Equal(t, []int32{0, 1, 2, 3, 4}, received)
+			assert.Equal(t, "5", stream.ResponseTrailer().Get("X-Count"))
+		})
+	}
+}
+
+func TestStreamOutlivesDefaultTimeoutsNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const (
+			procedure = "/test.v1.TestService/Tick"
+			timeout   = 300 * time.Millisecond
+			ticks     = 6
+		)
+		handler := connect.NewServerStreamHandler(procedure, func(
+			ctx context.Context,
+			_ *connect.Request[emptypb.Empty],
+			stream *connect.ServerStream[wrapperspb.Int32Value],
+		) error {
+			for i := range int32(ticks) {
+				if err := stream.Send(wrapperspb.Int32(i)); err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(timeout / 2):
+				}
+			}
+			return nil
+		})
+		startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithDefaultTimeout(timeout))
+
+		// the stream takes longer than the client and the worker default timeouts, but it is never idle for that long
+		nc := testnats.MustConnect(t, ctx, port)
+		client := connect.NewClient[emptypb.Empty, wrapperspb.Int32Value](
+			apiworker.NewHTTPClient(nc, apiworker.WithClientTimeout(timeout)), "nats://"+procedure)
+		stream, err := client.CallServerStream(ctx, connect.NewRequest(&emptypb.Empty{}))
+		require.NoError(t, err)
+
+		received := 0
+		for stream.Receive() {
+			received++
+		}
+		require.NoError(t, stream.Err())
+		require.NoError(t, stream.Close())
+		assert.Equal(t, ticks, received)
+	})
+}
+
+func TestStreamInterruptedByDeadlineNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const (
+			procedure = "/test.v1.TestService/Tick"
+			timeout   = 200 * time.Millisecond
+		)
+		handlerDone := make(chan struct{})
+		handler := connect.NewServerStreamHandler(procedure, func(
+			_ context.Context,
+			_ *connect.Request[emptypb.Empty],
+			stream *connect.ServerStream[wrapperspb.Int32Value],
+		) error {
+			defer close(handlerDone)
+			for i := range int32(2) {
+				if err := stream.Send(wrapperspb.Int32(i)); err != nil {
+					return err
+				}
+			}
+			// ignores the context on purpose
+			time.Sleep(2 * timeout)
+			return stream.Send(wrapperspb.Int32(2))
+		})
+		startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithDefaultTimeout(timeout))
+
+		nc := testnats.MustConnect(t, ctx, port)
+		replies, err := nc.SubscribeSync("_INBOX.>")
+		require.NoError(t, err)
+		client := connect.NewClient[emptypb.Empty, wrapperspb.Int32Value](apiworker.NewHTTPClient(nc), "nats://"+procedure)
+
+		// act
+		stream, err := client.CallServerStream(ctx, connect.NewRequest(&emptypb.Empty{}))
+		require.NoError(t, err)
+		var received []int32
+		for stream.Receive() {
+			received = append(received, stream.Msg().GetValue())
+		}
+		<-handlerDone
+
+		// assert
+		assert.Equal(t, []int32{0, 1}, received)
+		require.Error(t, stream.Err())
+		assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(stream.Err()))
+		require.NoError(t, stream.Close())
+		// the error is the last message of the reply stream, the handler messages are not published afterwards
+		var last *nats.Msg
+		for seq := 0; ; seq++ {
+			msg, err := replies.NextMsg(100 * time.Millisecond)
+			if errors.Is(err, nats.ErrTimeout) {
+				break
+			}
+			require.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(seq), msg.Header.Get(apiworker.StreamSeqHeader))
+			last = msg
+		}
+		require.NotNil(t, last)
+		assert.Equal(t, "true", last.Header.Get(apiworker.StreamEndHeader))
+		assert.Equal(t, connect.CodeDeadlineExceeded.String(), last.Header.Get(apiworker.StreamErrorHeader))
+	})
+}
+
+func TestRequestDeadlineExceededNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const procedure = "/test.v1.TestService/Sleep"
+		handler := connect.NewUnaryHandler(procedure, func(
+			_ context.Context,
+			req *connect.Request[durationpb.Duration],
+		) (*connect.Response[emptypb.Empty], error) {
+			// ignores the context on purpose
+			time.Sleep(req.Msg.AsDuration())
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		})
+		startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler))
+
+		nc := testnats.MustConnect(t, ctx, port)
+		msg := nats.NewMsg(testnats.PathToSubject(procedure))
+		msg.Header.Set("Connect-Timeout-Ms", "100")
+		msg.Data = []byte(`"3s"`)
+		started := time.Now()
+		reply, err := nc.RequestMsgWithContext(ctx, msg)
+
+		require.NoError(t, err)
+		assert.Less(t, time.Since(started), time.Second)
+		assert.Equal(t, "504", reply.Header.Get("X-Status-Code"))
+		gwError := sharedv1.Error{}
+		require.NoError(t, protojson.Unmarshal(reply.Data, &gwError))
+		assert.Equal(t, int32(504), gwError.GetCode())
+		assert.Equal(t, context.DeadlineExceeded.Error(), gwError.GetMessage())
+	})
+}
+
+func TestRequestDeadlineKeepsInFlightSlotNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const procedure = "/test.v1.TestService/Sleep"
+		var running, maxRunning atomic.Int32
+		handler := connect.NewUnaryHandler(procedure, func(
+			_ context.Context,
+			req *connect.Request[durationpb.Duration],
+		) (*connect.Response[emptypb.Empty], error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for current := maxRunning.Load(); n > current && !maxRunning.CompareAndSwap(current, n); {
+				current = maxRunning.Load()
+			}
+			// ignores the context on purpose
+			time.Sleep(req.Msg.AsDuration())
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		})
+		startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithMaxInFlight(1))
+
+		nc := testnats.MustConnect(t, ctx, port)
+		request := func() *nats.Msg {
+			msg := nats.NewMsg(testnats.PathToSubject(procedure))
+			msg.Header.Set("Connect-Timeout-Ms", "100")
+			msg.Data = []byte(`"0.5s"`)
+			reply, err := nc.RequestMsgWithContext(ctx, msg)
+			require.NoError(t, err)
+			return reply
+		}
+
+		// act
+		first := request()
+		second := request()
+
+		// assert
+		assert.Equal(t, "504", first.Header.Get("X-Status-Code"))
+		assert.Equal(t, "504", second.Header.Get("X-Status-Code"))
+		// the second request waits for the abandoned handler of the first one
+		assert.Equal(t, int32(1), maxRunning.Load())
+	})
+}
+
+func TestContextConfiguresConnectionNATS(t *testing.T) {
+	t.Parallel()
+	natsPort := testbind.DynamicPort()
+	metricsPort := testbind.DynamicPort()
+	ctx, cancel := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer cancel()
+	ns := testnats.MustRunNatsServer(t, ctx, natsPort)
+	defer ns.Shutdown()
+
+	contextFile := filepath.Join(t.TempDir(), "test.json")
+	natsContext := `{"url": "nats://` + net.JoinHostPort(Host, strconv.Itoa(natsPort)) + `", "inbox_prefix": "_TEST_INBOX"}`
+	require.NoError(t, os.WriteFile(contextFile, []byte(natsContext), 0o600))
+
 	serveCommand := cmd.CreateAPIWorkerCommand(ctx)
 	serveCommand.Command().SetArgs([]string{
-		"--server=" + address,
-		"--metrics=" + metricsAddress,
+		"--context=" + contextFile,
+		"--metrics=" + net.JoinHostPort(Host, strconv.Itoa(metricsPort)),
 	})
 	go func() {
-		errCh <- serveCommand.Command().ExecuteContext(ctx)
+		_ = serveCommand.Command().ExecuteContext(insights.ContextWithRegistry(ctx, insights.NewMetricsRegistry()))
 	}()
 	testbind.MustWaitForPortListenUp(ctx, t, metricsPort)
+
+	nc := testnats.MustConnect(t, ctx, natsPort)
+	reply := testnats.MustRequest(t, ctx, nc, versionv1connect.VersionServiceGetVersionProcedure, []byte("{}"))
+	assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+	cancel()
+	testbind.MustWaitForPortListenDown(ctx, t, metricsPort)
+}
+
+func TestRetryOnFailedConnectWaitsForServerNATS(t *testing.T) {
+	t.Parallel()
+	natsPort := testbind.DynamicPort()
+	metricsPort := testbind.DynamicPort()
+	ctx, cancel := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer cancel()
+
+	// the worker starts before the server
+	startWorker(ctx, t, natsPort, metricsPort,
+		"--retry-on-failed-connect", "--max-reconnects=-1", "--reconnect-wait=100ms")
+	resp := testhttp.MustGET(ctx, t, endpointURL("http://localhost:{{port}}/readyz", metricsPort))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	_ = resp.Body.Close()
+	ns := testnats.MustRunNatsServer(t, ctx, natsPort)
+	defer ns.Shutdown()
+
+	assert.Eventually(t, func() bool {
+		resp := testhttp.MustGET(ctx, t, endpointURL("http://localhost:{{port}}/metrics", metricsPort))
+		defer func() { _ = resp.Body.Close() }()
+		return strings.Contains(testhttp.MustReadFullyString(t, resp), `nats_worker_connection_status{status="CONNECTED"} 1`)
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// subscriptions are registered right after the connection
+	nc := testnats.MustConnect(t, ctx, natsPort)
+	assert.Eventually(t, func() bool {
+		reply, err := testnats.Request(t, ctx, nc, versionv1connect.VersionServiceGetVersionProcedure, []byte("{}"))
+		return err == nil && reply.Header.Get("X-Status-Code") == "200"
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestEmbeddedServerNATS(t *testing.T) {
+	t.Parallel()
+	natsPort := testbind.DynamicPort()
+	metricsPort := testbind.DynamicPort()
+	ctx, cancel := context.WithCancel(context.WithoutCancel(rootTestCtx))
+	defer cancel()
+
+	errCh := startWorker(ctx, t, natsPort, metricsPort, "--embedded",
+		"--embedded-listen="+net.JoinHostPort(Host, strconv.Itoa(natsPort)),
+		"--embedded-jetstream", "--embedded-store-dir="+t.TempDir())
+
+	nc := testnats.MustConnect(t, ctx, natsPort)
+	reply := testnats.MustRequest(t, ctx, nc, versionv1connect.VersionServiceGetVersionProcedure, []byte("{}"))
+	assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	_, err = js.AccountInfo(ctx)
+	require.NoError(t, err)
+
+	// the embedded server stops with the worker
+	cancel()
+	require.NoError(t, <-errCh)
+	testbind.MustWaitForPortListenDown(rootTestCtx, t, natsPort)
+}
+
+func TestAdminBroadcastAndDrainNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		startWorker(ctx, t, port, testbind.DynamicPort())
+		nc := testnats.MustConnect(t, ctx, port)
+
+		// scatter-gather: every instance replies
+		replies := adminScatterGather(t, nc, apiworker.AdminSubject("", version.ServiceName, "", apiworker.AdminVersion), 2)
+		require.Len(t, replies, 2)
+		assert.NotEqual(t, replies[0].Instance, replies[1].Instance)
+		for _, reply := range replies {
+			resp := versionv1.GetVersionResponse{}
+			require.NoError(t, protojson.Unmarshal(reply.Version, &resp))
+			assert.Equal(t, version.FullVersion, resp.GetVersion().GetFullVersion())
+			assert.False(t, reply.Draining)
+		}
+
+		// drain a single instance, the other one keeps serving
+		drained := adminScatterGather(t, nc, apiworker.AdminSubject("", version.ServiceName, replies[0].Instance, apiworker.AdminDrain), 1)
+		require.Len(t, drained, 1)
+		assert.Equal(t, replies[0].Instance, drained[0].Instance)
+		assert.True(t, drained[0].Draining)
+		for range 5 {
+			reply := testnats.MustRequest(t, ctx, nc, versionv1connect.VersionServiceGetVersionProcedure, []byte("{}"))
+			assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+		}
+	})
+}
+
+func TestAdminSubjectPrefixNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		// the default worker has no subject prefix
+		startWorker(ctx, t, port, testbind.DynamicPort(), "--subject-prefix=prod.")
+		startWorker(ctx, t, port, testbind.DynamicPort(), "--subject-prefix=staging.")
+		nc := testnats.MustConnect(t, ctx, port)
+
+		// every namespace is answered by its own instances only
+		prod := adminScatterGather(t, nc, apiworker.AdminSubject("prod.", version.ServiceName, "", apiworker.AdminStatus), 3)
+		staging := adminScatterGather(t, nc, apiworker.AdminSubject("staging.", version.ServiceName, "", apiworker.AdminStatus), 3)
+		unprefixed := adminScatterGather(t, nc, apiworker.AdminSubject("", version.ServiceName, "", apiworker.AdminStatus), 3)
+
+		require.Len(t, prod, 1)
+		require.Len(t, staging, 1)
+		require.Len(t, unprefixed, 1)
+		assert.NotEqual(t, prod[0].Instance, staging[0].Instance)
+		assert.NotEqual(t, prod[0].Instance, unprefixed[0].Instance)
+		assert.NotEqual(t, staging[0].Instance, unprefixed[0].Instance)
+	})
+}
+
+// adminScatterGather publishes the admin request and collects up to n replies.
+func adminScatterGather(t *testing.T, nc *nats.Conn, subject string, n int) []apiworker.AdminReply {
+	t.Helper()
+
+	sub, err := nc.SubscribeSync(nc.NewRespInbox())
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+	require.NoError(t, nc.PublishRequest(subject, sub.Subject, nil))
+
+	var replies []apiworker.AdminReply
+	for len(replies) < n {
+		msg, err := sub.NextMsg(time.Second)
+		if errors.Is(err, nats.ErrTimeout) {
+			break
+		}
+		require.NoError(t, err)
+		reply := apiworker.AdminReply{}
+		require.NoError(t, json.Unmarshal(msg.Data, &reply))
+		require.Empty(t, reply.Error)
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+func TestMaxInFlightHandlesRequestsConcurrentlyNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const (
+			procedure = "/test.v1.TestService/Sleep"
+			requests  = 4
+			sleep     = 500 * time.Millisecond
+		)
+		handler := connect.NewUnaryHandler(procedure, func(
+			_ context.Context,
+			req *connect.Request[durationpb.Duration],
+		) (*connect.Response[emptypb.Empty], error) {
+			time.Sleep(req.Msg.AsDuration())
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		})
+		startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithMaxInFlight(requests))
+
+		nc := testnats.MustConnect(t, ctx, port)
+		started := time.Now()
+		var wg sync.WaitGroup
+		for range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reply, err := nc.RequestWithContext(ctx, testnats.PathToSubject(procedure), []byte(`"0.5s"`))
+				if assert.NoError(t, err) {
+					assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Less(t, time.Since(started), requests*sleep/2)
+	})
+}
+
+func TestShutdownOnCanceledContextCompletesRequestsInFlightNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const procedure = "/test.v1.TestService/Sleep"
+		started := make(chan struct{}, 1)
+		handler := connect.NewUnaryHandler(procedure, func(
+			ctx context.Context,
+			req *connect.Request[durationpb.Duration],
+		) (*connect.Response[emptypb.Empty], error) {
+			started <- struct{}{}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(req.Msg.AsDuration()):
+			}
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		})
+		// the worker is served with the context canceled on signal, like the nats command
+		serveCtx, cancel := context.WithCancel(ctx)
+		wrk := startCustomWorker(serveCtx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithDrainTimeout(5*time.Second))
+		nc := testnats.MustConnect(t, ctx, port)
+		replyCh := make(chan *nats.Msg, 1)
+		go func() {
+			defer close(replyCh)
+			reply, err := nc.RequestWithContext(ctx, testnats.PathToSubject(procedure), []byte(`"0.5s"`))
+			if err == nil {
+				replyCh <- reply
+			}
+		}()
+		<-started
+
+		// act: the signal cancels the serve context before the shutdown
+		cancel()
+		require.NoError(t, wrk.Shutdown(context.WithoutCancel(ctx)))
+
+		// assert: the request in flight is completed, not canceled
+		reply, ok := <-replyCh
+		require.True(t, ok, "no reply to the request in flight")
+		assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+	})
+}
+
+func TestShutdownCompletesRequestsInFlightNATS(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port, _ int) {
+		const procedure = "/test.v1.TestService/Sleep"
+		started := make(chan struct{}, 2)
+		handler := connect.NewUnaryHandler(procedure, func(
+			_ context.Context,
+			req *connect.Request[durationpb.Duration],
+		) (*connect.Response[emptypb.Empty], error) {
+			started <- struct{}{}
+			time.Sleep(req.Msg.AsDuration())
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		})
+		nc := testnats.MustConnect(t, ctx, port)
+		request := func(sleep string) <-chan *nats.Msg {
+			replyCh := make(chan *nats.Msg, 1)
+			go func() {
+				defer close(replyCh)
+				reqCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+				defer cancel()
+				reply, err := nc.RequestWithContext(reqCtx, testnats.PathToSubject(procedure), []byte(`"`+sleep+`"`))
+				if err == nil {
+					replyCh <- reply
+				}
+			}()
+			return replyCh
+		}
+
+		// the request in flight completes within the drain timeout
+		wrk := startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithMaxInFlight(2), apiworker.WithDrainTimeout(5*time.Second))
+		replyCh := request("0.5s")
+		<-started
+		require.NoError(t, wrk.Shutdown(ctx))
+		reply, ok := <-replyCh
+		require.True(t, ok, "no reply to the request in flight")
+		assert.Equal(t, "200", reply.Header.Get("X-Status-Code"))
+
+		// the request in flight is abandoned once the drain timeout expires
+		wrk = startCustomWorker(ctx, t, port, apiserv.NewRoute("/test.v1.TestService/", handler),
+			apiworker.WithDrainTimeout(100*time.Millisecond))
+		replyCh = request("2s")
+		<-started
+		shutdownStarted := time.Now()
+		require.NoError(t, wrk.Shutdown(ctx))
+		assert.Less(t, time.Since(shutdownStarted), time.Second)
+		_, ok = <-replyCh
+		assert.False(t, ok, "abandoned request replied")
+	})
+}
+
+func runTest(t *testing.T, test func(ctx context.Context, natsPort, metricsPort int)) {
+	t.Helper()
+
+	natsServerPort := testbind.DynamicPort()
+	metricsPort := testbind.DynamicPort()
+
+	ctx := context.WithoutCancel(rootTestCtx)
+	ctx, stopMain := context.WithCancel(ctx)
+	defer stopMain()
+
+	ns := testnats.MustRunNatsServer(t, ctx, natsServerPort)
+	defer ns.Shutdown()
+
+	startWorker(ctx, t, natsServerPort, metricsPort)
 	test(ctx, natsServerPort, metricsPort)
 	stopMain()
 	// Full shutdown of the server may take 3 more seconds.
-	// Uncomment this line if there is a need to check the return error.
+	// Read from the channel returned by startWorker if there is a need to check the return error.
 	// <-errCh
 	//
 	// Instead, we can just wait for the port to be taken down.
@@ -128,6 +1149,62 @@ func runTest(t *testing.T, test func(ctx context.Context, natsPort, metricsPort
 	testbind.MustWaitForPortListenDown(ctx, t, natsServerPort)
 }
 
+// startWorker runs the nats command in background until ctx is done and waits for it to be ready.
+// The returned channel receives the command result.
+func startWorker(ctx context.Context, t *testing.T, natsPort, metricsPort int, args ...string) <-chan error {
+	t.Helper()
+
+	// each worker registers its own metrics and health statuses
+	ctx = insights.ContextWithRegistry(ctx, insights.NewMetricsRegistry())
+	ctx = health.ContextWithRegistry(ctx, health.NewRegistry())
+	address := "nats://" + net.JoinHostPort(Host, strconv.Itoa(natsPort))
+	metricsAddress := net.JoinHostPort(Host, strconv.Itoa(metricsPort))
+	serveCommand := cmd.CreateAPIWorkerCommand(ctx)
+	serveCommand.Command().SetArgs(append([]string{
+		"--server=" + address,
+		"--metrics=" + metricsAddress,
+	}, args...))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serveCommand.Command().ExecuteContext(ctx)
+	}()
+	testbind.MustWaitForPortListenUp(ctx, t, metricsPort)
+	return errCh
+}
+
+// startCustomWorker runs the worker serving the given route until the test ends and waits for it to be ready.
+func startCustomWorker(ctx context.Context, t *testing.T, natsPort int, route apiserv.Route, options ...apiworker.Option) apiworker.Worker {
+	t.Helper()
+	return startCustomWorkerWithMetrics(ctx, t, natsPort, testbind.DynamicPort(), route, options...)
+}
+
+// startCustomWorkerWithMetrics is startCustomWorker with metrics and probes served on the metricsPort.
+func startCustomWorkerWithMetrics(
+	ctx context.Context,
+	t *testing.T,
+	natsPort, metricsPort int,
+	route apiserv.Route,
+	options ...apiworker.Option,
+) apiworker.Worker {
+	t.Helper()
+
+	ctx = insights.ContextWithRegistry(ctx, insights.NewMetricsRegistry())
+	ctx = health.ContextWithRegistry(ctx, health.NewRegistry())
+	address := "nats://" + net.JoinHostPort(Host, strconv.Itoa(natsPort))
+	options = append(options, apiworker.WithMetricsAddress(net.JoinHostPort(Host, strconv.Itoa(metricsPort))))
+	wrk, err := apiworker.NewWorker(ctx, address, []apiserv.Route{route}, options...)
+	require.NoError(t, err)
+	go func() {
+		_ = wrk.ListenAndServe(ctx)
+	}()
+	t.Cleanup(func() {
+		_ = wrk.Shutdown(context.WithoutCancel(ctx))
+	})
+	// close the probe connection, the metrics server shutdown waits for open connections
+	_ = testbind.MustWaitForPortListenUp(ctx, t, metricsPort).Close()
+	return wrk
+}
+
 func endpointURL(url string, port int, parts ...string) string {
 	base := strings.ReplaceAll(url, "{{port}}", strconv.Itoa(port))
 	return base + strings.Join(parts, "/")
@@ -141,10 +1218,10 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(err)
 	}
-	m.Run()
+	code := m.Run()
 	err = otelShutdown(rootTestCtx)
 	if err != nil {
 		panic(err)
 	}
-	os.Exit(0)
+	os.Exit(code)
 }
@@ -2,27 +2,44 @@ package servehttpe2e_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/leonardinius/go-service-template/app/cmd"
 	"github.com/leonardinius/go-service-template/internal/apigen/version/v1/versionv1connect"
+	"github.com/leonardinius/go-service-template/internal/apiserv"
+	"github.com/leonardinius/go-service-template/internal/health"
 	"github.com/leonardinius/go-service-template/internal/insights"
+	"github.com/leonardinius/go-service-template/internal/services/healthcheck"
+	"github.com/leonardinius/go-service-template/internal/services/serviceotel"
 	"github.com/leonardinius/go-service-template/internal/services/version"
 	"github.com/leonardinius/go-service-template/teste2e/internal/testbind"
 	"github.com/leonardinius/go-service-template/teste2e/internal/testhttp"
 
+	sharedv1 "github.com/leonardinius/go-service-template/internal/apigen/shared/v1"
 	versionv1 "github.com/leonardinius/go-service-template/internal/apigen/version/v1"
+	testv1 "github.com/leonardinius/go-service-template/teste2e/internal/testapi/test/v1"
 )
 
+const healthCheckProcedure = "/" + healthcheck.HealthServiceName + "/Check"
+
 func TestServeHTTPVersionInfoConnectHTTP(t *testing.T) {
 	t.Parallel()
 	runTest(t, func(ctx context.Context, port int) {
@@ -112,22 +129,288 @@ func TestOtelHasXTraceHeadersGrpc(t *testing.T) {
 	})
 }
 
+func TestPanicRecoveryConnectAndGrpc(t *testing.T) {
+	t.Parallel()
+	const procedure = "/test.v1.TestService/Panic"
+	port := testbind.DynamicPort()
+	ctx, stop := context.WithCancel(insights.ContextWithRegistry(rootTestCtx, insights.NewMetricsRegistry()))
+	defer stop()
+	handler := connect.NewUnaryHandler(procedure, func(
+		context.Context,
+		*connect.Request[emptypb.Empty],
+	) (*connect.Response[emptypb.Empty], error) {
+		panic("hello, panic!")
+	}, connect.WithInterceptors(serviceotel.DefaultServicesInterceptors()...))
+	srv, err := apiserv.NewDefaultServer(ctx, fmt.Sprintf("localhost:%d", port), []apiserv.Route{apiserv.NewRoute(procedure, handler)})
+	require.NoError(t, err)
+	go func() {
+		_ = apiserv.ListenAndServe(ctx, srv)
+	}()
+	testbind.MustWaitForPortListenUp(ctx, t, port)
+
+	for _, option := range []connect.ClientOption{connect.WithProtoJSON(), connect.WithGRPC()} {
+		client := connect.NewClient[emptypb.Empty, emptypb.Empty](&http.Client{},
+			endpointURL("http://localhost:{{port}}", port, procedure), option)
+
+		_, err := client.CallUnary(ctx, connect.NewRequest(&emptypb.Empty{}))
+
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, connect.CodeInternal, connectErr.Code())
+		assert.Equal(t, "internal error", connectErr.Message())
+		require.Len(t, connectErr.Details(), 1)
+		detail, err := connectErr.Details()[0].Value()
+		require.NoError(t, err)
+		sharedErr, ok := detail.(*sharedv1.Error)
+		require.True(t, ok)
+		require.Len(t, sharedErr.GetDetails(), 1)
+		assert.Regexp(t, "^trace_id: [0-9a-f]{32}$", sharedErr.GetDetails()[0])
+	}
+
+	resp := testhttp.MustGET(ctx, t, endpointURL("http://localhost:{{port}}/metrics", port))
+	assert.Contains(t, testhttp.MustReadFullyString(t, resp), `panics_total{procedure="`+procedure+`"} 2`)
+	_ = resp.Body.Close()
+}
+
+func TestHealthCheckGrpc(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port int) {
+		client := connect.NewClient[testv1.HealthCheckRequest, testv1.HealthCheckResponse](&http.Client{},
+			endpointURL("http://localhost:{{port}}", port, healthCheckProcedure),
+			connect.WithGRPC(),
+		)
+
+		for _, service := range []string{health.Server, "version.v1.VersionService"} {
+			resp, err := client.CallUnary(ctx, connect.NewRequest(&testv1.HealthCheckRequest{Service: service}))
+
+			require.NoError(t, err, service)
+			assert.Equal(t, testv1.HealthCheckResponse_SERVING_STATUS_SERVING, resp.Msg.GetStatus(), service)
+		}
+
+		_, err := client.CallUnary(ctx, connect.NewRequest(&testv1.HealthCheckRequest{Service: "unknown.v1.Service"}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
+
+func TestHealthProbesFollowReadinessChecks(t *testing.T) {
+	t.Parallel()
+	registry := health.NewRegistry()
+	var dbDown atomic.Bool
+	dbDown.Store(true)
+	registry.RegisterReadiness("db", health.CheckerFunc(func(context.Context) error {
+		if dbDown.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}))
+	runTestWithHealth(t, registry, func(ctx context.Context, port int) {
+		client := connect.NewClient[testv1.HealthCheckRequest, testv1.HealthCheckResponse](&http.Client{},
+			endpointURL("http://localhost:{{port}}", port, healthCheckProcedure),
+			connect.WithGRPC(),
+		)
+		probe := func(path string) (int, health.Report) {
+			resp := testhttp.MustGET(ctx, t, endpointURL("http://localhost:{{port}}", port, path))
+			var report health.Report
+			testhttp.MustReadFullyJSON(t, resp, &report)
+			_ = resp.Body.Close()
+			return resp.StatusCode, report
+		}
+
+		// the failing critical check fails the readiness, the server is alive
+		statusCode, report := probe(apiserv.ReadyzRoutePath)
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Equal(t, health.CheckStatusFail, report.Checks["db"].Status)
+		assert.Equal(t, "connection refused", report.Checks["db"].Message)
+		statusCode, _ = probe(apiserv.HealthzRoutePath)
+		assert.Equal(t, http.StatusOK, statusCode)
+		resp, err := client.CallUnary(ctx, connect.NewRequest(&testv1.HealthCheckRequest{}))
+		require.NoError(t, err)
+		assert.Equal(t, testv1.HealthCheckResponse_SERVING_STATUS_NOT_SERVING, resp.Msg.GetStatus())
+
+		// the check recovers
+		dbDown.Store(false)
+		statusCode, report = probe(apiserv.ReadyzRoutePath)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, health.CheckStatusOK, report.Checks["db"].Status)
+		resp, err = client.CallUnary(ctx, connect.NewRequest(&testv1.HealthCheckRequest{}))
+		require.NoError(t, err)
+		assert.Equal(t, testv1.HealthCheckResponse_SERVING_STATUS_SERVING, resp.Msg.GetStatus())
+	})
+}
+
+func TestMutualTLSWithGeneratedCertsGrpc(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	certsCommand := cmd.CreateCertsCommand(rootTestCtx)
+	certsCommand.Command().SetArgs([]string{"--dir=" + dir, "--client=alice"})
+	require.NoError(t, certsCommand.Command().ExecuteContext(rootTestCtx))
+	caPEM, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM))
+	alice, err := tls.LoadX509KeyPair(filepath.Join(dir, "client-alice.pem"), filepath.Join(dir, "client-alice-key.pem"))
+	require.NoError(t, err)
+
+	runTestWithHealth(t, health.NewRegistry(), func(ctx context.Context, port int) {
+		newClient := func(certs ...tls.Certificate) versionv1connect.VersionServiceClient {
+			return versionv1connect.NewVersionServiceClient(&http.Client{Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12},
+				ForceAttemptHTTP2: true,
+			}}, endpointURL("https://localhost:{{port}}", port), connect.WithGRPC())
+		}
+
+		resp, err := newClient(alice).GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+		require.NoError(t, err)
+		assert.Equal(t, version.FullVersion, resp.Msg.GetVersion().GetFullVersion())
+
+		_, err = newClient().GetVersion(ctx, connect.NewRequest(&versionv1.GetVersionRequest{}))
+		require.Error(t, err)
+	},
+		"--tls-cert="+filepath.Join(dir, "server.pem"),
+		"--tls-key="+filepath.Join(dir, "server-key.pem"),
+		"--tls-client-ca="+filepath.Join(dir, "ca.pem"),
+	)
+}
+
+func TestTLSDevServesHTTP2(t *testing.T) {
+	t.Parallel()
+	runTestWithHealth(t, health.NewRegistry(), func(ctx context.Context, port int) {
+		client := &http.Client{Transport: &http.Transport{
+			// the development certificate is generated in memory, it is not trusted
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}, //nolint:gosec // test
+			ForceAttemptHTTP2: true,
+		}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			endpointURL("https://localhost:{{port}}", port, apiserv.ReadyzRoutePath), nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, resp.ProtoMajor)
+		assert.Contains(t, resp.TLS.PeerCertificates[0].DNSNames, "localhost")
+	}, "--tls-dev")
+}
+
+func TestCORSPreflightConnectWeb(t *testing.T) {
+	t.Parallel()
+	runTestWithHealth(t, health.NewRegistry(), func(ctx context.Context, port int) {
+		url := endpointURL("http://localhost:{{port}}", port, versionv1connect.VersionServiceGetVersionProcedure)
+		req, err := http.NewRequestWithContext(ctx, http.MethodOptions, url, nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,connect-protocol-version,connect-timeout-ms")
+
+		resp, err := http.DefaultClient.Do(req)
+
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "http://localhost:3000", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "content-type,connect-protocol-version,connect-timeout-ms", resp.Header.Get("Access-Control-Allow-Headers"))
+
+		// the same origin request has no CORS headers
+		post := testhttp.MustPost(ctx, t, url, "application/json", strings.NewReader("{}"))
+		_ = post.Body.Close()
+		assert.Empty(t, post.Header.Get("Access-Control-Allow-Origin"))
+
+		// the Connect request of the allowed origin can read the trace ID
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader("{}"))
+		require.NoError(t, err)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Access-Control-Expose-Headers"), "X-Trace-Id")
+	}, "--cors-origin=http://localhost:*")
+}
+
+func TestServerReflectionGrpc(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(ctx context.Context, port int) {
+		// the reflection stream is bidi, it needs HTTP/2
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		client := grpcreflect.NewClient(&http.Client{Transport: &http.Transport{Protocols: protocols}},
+			endpointURL("http://localhost:{{port}}", port),
+			connect.WithGRPC(),
+		)
+		stream := client.NewStream(ctx)
+		defer func() { _, _ = stream.Close() }()
+
+		services, err := stream.ListServices()
+
+		require.NoError(t, err)
+		assert.Contains(t, services, protoreflect.FullName("version.v1.VersionService"))
+		assert.Contains(t, services, protoreflect.FullName(healthcheck.HealthServiceName))
+	})
+}
+
+func TestLameDuckShutdownFailsReadiness(t *testing.T) {
+	t.Parallel()
+	port := testbind.DynamicPort()
+	ctx := insights.ContextWithRegistry(context.WithoutCancel(rootTestCtx), insights.NewMetricsRegistry())
+	ctx = health.ContextWithRegistry(ctx, health.NewRegistry())
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	serveCommand := cmd.CreateHTTPServeCommand(ctx)
+	serveCommand.Command().SetArgs([]string{
+		"--address=" + fmt.Sprintf("localhost:%d", port),
+		"--pre-stop-delay=500ms",
+		"--shutdown-timeout=1s",
+	})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serveCommand.Command().ExecuteContext(ctx)
+	}()
+	_ = testbind.MustWaitForPortListenUp(ctx, t, port).Close()
+	readyz := endpointURL("http://localhost:{{port}}", port, apiserv.ReadyzRoutePath)
+	probeCtx := context.WithoutCancel(ctx)
+
+	resp := testhttp.MustGET(probeCtx, t, readyz)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the server is lame duck: not ready, but still serving
+	stop()
+	require.Eventually(t, func() bool {
+		resp := testhttp.MustGET(probeCtx, t, readyz)
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, <-errCh)
+	testbind.MustWaitForPortListenDown(probeCtx, t, port)
+}
+
 func runTest(t *testing.T, test func(ctx context.Context, port int)) {
 	t.Helper()
+	runTestWithHealth(t, health.NewRegistry(), test)
+}
+
+// runTestWithHealth runs the http command with the health registry and the extra args.
+func runTestWithHealth(t *testing.T, registry *health.Registry, test func(ctx context.Context, port int), args ...string) {
+	t.Helper()
 
 	port := testbind.DynamicPort()
 
 	ctx := context.WithoutCancel(rootTestCtx)
 	ctx = insights.ContextWithRegistry(ctx, insights.NewMetricsRegistry())
+	ctx = health.ContextWithRegistry(ctx, registry)
 	ctx, stopMain := context.WithCancel(ctx)
 	defer stopMain()
 
 	address := fmt.Sprintf("localhost:%d", port)
 	errCh := make(chan error, 1)
 	serveCommand := cmd.CreateHTTPServeCommand(ctx)
-	serveCommand.Command().SetArgs([]string{
+	serveCommand.Command().SetArgs(append([]string{
 		"--address=" + address,
-	})
+	}, args...))
 	go func() {
 		errCh <- serveCommand.Command().ExecuteContext(ctx)
 	}()
@@ -158,10 +441,10 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(err)
 	}
-	m.Run()
+	code := m.Run()
 	err = otelShutdown(rootTestCtx)
 	if err != nil {
 		panic(err)
 	}
-	os.Exit(0)
+	os.Exit(code)
 }